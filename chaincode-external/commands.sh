@@ -5,9 +5,18 @@ cd worldtradex/wtx-supplychain-fabric-network
 ./network.sh up createChannel -s couchdb
 
 # Set Chaincode PATH & Deploy the chaincode
-./network.sh deployCC -ccn ordermanagement -ccp /Users/macbook/neel/Projects/worldtradex/wtx-supplychain-fabric-network/chaincode-external -ccl go
-
-./network.sh deployCCAAS  -ccn ordermanagement -ccp /Users/macbook/neel/Projects/worldtradex/wtx-supplychain-fabric-network/chaincode-external
+# In-peer deployment builds the repository root, chaincode-as-a-service deployment uses chaincode-external
+# The CouchDB indexes are read from META-INF/statedb/couchdb/indexes of the package and those of
+# the private data collections from META-INF/statedb/couchdb/collections, copy the
+# META-INF directory of the repository root into chaincode-external before packaging it as a service
+# The private data collections holding invoices, line items and payment accounts are declared
+# in collections_config.json of the repository root and must be passed with -cccg on every deployment.
+# Invoices and line items are only held by Org1MSP, which sells and buys, and payment accounts by
+# Org2MSP, which holds the bank role. Other organizations see their hashes and can check details
+# they were given with VerifyPrivateHash
+./network.sh deployCC -ccn ordermanagement -ccp /Users/macbook/neel/Projects/worldtradex/wtx-supplychain-fabric-network -ccl go -cccg /Users/macbook/neel/Projects/worldtradex/wtx-supplychain-fabric-network/collections_config.json
+
+./network.sh deployCCAAS  -ccn ordermanagement -ccp /Users/macbook/neel/Projects/worldtradex/wtx-supplychain-fabric-network/chaincode-external -cccg /Users/macbook/neel/Projects/worldtradex/wtx-supplychain-fabric-network/collections_config.json
 
 # ENV
 export CORE_PEER_TLS_ENABLED=true
@@ -19,7 +28,7 @@ export PATH=../bin:$PATH
 export FABRIC_CFG_PATH=$PWD/../config
 
 
-docker build -f ./Dockerfile -t basicj_ccaas_image:latest --build-arg CC_SERVER_PORT=9999 ./chaincode-external
+docker build -f ./chaincode-external/Dockerfile -t basicj_ccaas_image:latest --build-arg CC_SERVER_PORT=9999 .
 
 
  docker run --rm -it -p 9229:9229 --name peer0org2_ordermanagement_ccaas --network fabric_test -e DEBUG=true -e CHAINCODE_SERVER_ADDRESS=0.0.0.0:9999 -e CHAINCODE_ID=ordermanagement_1.0:7c7dff5cdc43c77ccea028c422b3348c3c1fb5a26ace0077cf3cc627bd355ef0 -e CORE_CHAINCODE_ID_NAME=ordermanagement_1.0:7c7dff5cdc43c77ccea028c422b3348c3c1fb5a26ace0077cf3cc627bd355ef0 ordermanagement_ccaas_image:latest
@@ -29,6 +38,10 @@ docker build -f ./Dockerfile -t basicj_ccaas_image:latest --build-arg CC_SERVER_
 
 
 # Invoke the chaincode 
+# Callers need a certificate carrying a role attribute (admin, buyer, seller, carrier, auditor or bank),
+# e.g. fabric-ca-client register --id.attrs 'role=admin:ecert'
+# The admin role is only accepted from Org1MSP and the bank and carrier roles from Org2MSP,
+# see CHAINCODE_ADMIN_MSPIDS, CHAINCODE_BANK_MSPIDS and CHAINCODE_CARRIER_MSPIDS in chaincode.env
 peer chaincode invoke -o localhost:7050 --ordererTLSHostnameOverride orderer.example.com --tls --cafile "$PWD/organizations/ordererOrganizations/example.com/orderers/orderer.example.com/msp/tlscacerts/tlsca.example.com-cert.pem" -C mychannel -n ordermanagement --peerAddresses localhost:7051 --tlsRootCertFiles "$PWD/organizations/peerOrganizations/org1.example.com/peers/peer0.org1.example.com/tls/ca.crt" --peerAddresses localhost:9051 --tlsRootCertFiles "$PWD/organizations/peerOrganizations/org2.example.com/peers/peer0.org2.example.com/tls/ca.crt" -c '{"function":"InitOrder","Args":[]}'
 
 
@@ -43,7 +56,10 @@ peer chaincode query -C mychannel -n ordermanagement -c '{"Args":["ReadOrder","l
 
 
 # Transaction Chaincode Functions
-peer chaincode invoke -o localhost:7050 --ordererTLSHostnameOverride orderer.example.com --tls --cafile "$PWD/organizations/ordererOrganizations/example.com/orderers/orderer.example.com/msp/tlscacerts/tlsca.example.com-cert.pem" -C mychannel -n ordermanagement --peerAddresses localhost:7051 --tlsRootCertFiles "$PWD/organizations/peerOrganizations/org1.example.com/peers/peer0.org1.example.com/tls/ca.crt" --peerAddresses localhost:9051 --tlsRootCertFiles "$PWD/organizations/peerOrganizations/org2.example.com/peers/peer0.org2.example.com/tls/ca.crt" -c '{"Args":["CreateTransaction", "txn123", "ACH", "100.0", "1234567890", "Payment for services"]}'
+# The account of a transaction is private and is passed in the transient map, the salt must be
+# at least 16 characters long. An account passed as a parameter is rejected
+export PAYMENT_PRIVATE=$(echo -n '{"account":"1234567890","salt":"c2VjcmV0LXNhbHQtMTIz"}' | base64 | tr -d \\n)
+peer chaincode invoke -o localhost:7050 --ordererTLSHostnameOverride orderer.example.com --tls --cafile "$PWD/organizations/ordererOrganizations/example.com/orderers/orderer.example.com/msp/tlscacerts/tlsca.example.com-cert.pem" -C mychannel -n ordermanagement --peerAddresses localhost:7051 --tlsRootCertFiles "$PWD/organizations/peerOrganizations/org1.example.com/peers/peer0.org1.example.com/tls/ca.crt" --peerAddresses localhost:9051 --tlsRootCertFiles "$PWD/organizations/peerOrganizations/org2.example.com/peers/peer0.org2.example.com/tls/ca.crt" -c '{"Args":["CreateTransaction", "txn123", "logis_ordr_1", "ACH", "100.00", "USD", "", "Payment for services"]}' --transient "{\"private\":\"$PAYMENT_PRIVATE\"}"
 
 
 # Query Transaction from Transaction ID
@@ -0,0 +1,60 @@
+package fabrictest
+
+import (
+	"crypto/x509"
+	"fmt"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+)
+
+// ClientIdentity is a fixed cid.ClientIdentity holding an ID, an MSP ID and certificate attributes
+type ClientIdentity struct {
+	ID          string
+	MSPID       string
+	Attributes  map[string]string
+	Certificate *x509.Certificate
+}
+
+var _ cid.ClientIdentity = (*ClientIdentity)(nil)
+
+// NewClientIdentity returns an identity of the given MSP carrying the given role attribute
+func NewClientIdentity(id string, mspID string, role string) *ClientIdentity {
+	attributes := map[string]string{}
+	if role != "" {
+		attributes["role"] = role
+	}
+	return &ClientIdentity{ID: id, MSPID: mspID, Attributes: attributes}
+}
+
+// GetID returns the ID of the identity
+func (c *ClientIdentity) GetID() (string, error) {
+	return c.ID, nil
+}
+
+// GetMSPID returns the MSP ID of the identity
+func (c *ClientIdentity) GetMSPID() (string, error) {
+	return c.MSPID, nil
+}
+
+// GetAttributeValue returns the value of a certificate attribute
+func (c *ClientIdentity) GetAttributeValue(attrName string) (string, bool, error) {
+	value, found := c.Attributes[attrName]
+	return value, found, nil
+}
+
+// AssertAttributeValue checks that a certificate attribute holds the given value
+func (c *ClientIdentity) AssertAttributeValue(attrName, attrValue string) error {
+	value, found := c.Attributes[attrName]
+	if !found {
+		return fmt.Errorf("attribute '%s' was not found", attrName)
+	}
+	if value != attrValue {
+		return fmt.Errorf("attribute '%s' equals '%s', not '%s'", attrName, value, attrValue)
+	}
+	return nil
+}
+
+// GetX509Certificate returns the certificate of the identity, if any
+func (c *ClientIdentity) GetX509Certificate() (*x509.Certificate, error) {
+	return c.Certificate, nil
+}
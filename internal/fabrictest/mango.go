@@ -0,0 +1,267 @@
+package fabrictest
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+)
+
+// mangoQuery is the part of a CouchDB query the stub evaluates. Indexes, sorting and
+// field projections are ignored, results are returned in key order
+type mangoQuery struct {
+	Selector map[string]interface{} `json:"selector"`
+}
+
+// richQuery returns the committed JSON documents matching a CouchDB query, ordered by key
+func richQuery(values map[string][]byte, query string) ([]*queryresult.KV, error) {
+	var q mangoQuery
+	if err := json.Unmarshal([]byte(query), &q); err != nil {
+		return nil, fmt.Errorf("invalid query %s: %v", query, err)
+	}
+	if q.Selector == nil {
+		return nil, fmt.Errorf("query %s has no selector", query)
+	}
+
+	var results []*queryresult.KV
+	for _, kv := range scan(values, "", maxUnicodeRune) {
+		var doc map[string]interface{}
+		if err := json.Unmarshal(kv.Value, &doc); err != nil {
+			// Only JSON documents can be queried
+			continue
+		}
+		ok, err := matchSelector(q.Selector, doc)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			results = append(results, kv)
+		}
+	}
+	return results, nil
+}
+
+// matchSelector reports whether a document satisfies every condition of a selector
+func matchSelector(selector map[string]interface{}, doc interface{}) (bool, error) {
+	for key, condition := range selector {
+		var ok bool
+		var err error
+
+		switch key {
+		case "$and", "$or", "$nor":
+			ok, err = matchCombination(key, condition, doc)
+		case "$not":
+			sub, isMap := condition.(map[string]interface{})
+			if !isMap {
+				return false, fmt.Errorf("$not expects a selector")
+			}
+			ok, err = matchSelector(sub, doc)
+			ok = !ok
+		default:
+			if strings.HasPrefix(key, "$") {
+				return false, fmt.Errorf("operator %s is not supported at the selector level", key)
+			}
+			value, found := lookup(doc, key)
+			ok, err = matchCondition(condition, value, found)
+		}
+
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// matchCombination evaluates $and, $or and $nor over a list of selectors
+func matchCombination(operator string, condition interface{}, doc interface{}) (bool, error) {
+	selectors, ok := condition.([]interface{})
+	if !ok {
+		return false, fmt.Errorf("%s expects a list of selectors", operator)
+	}
+
+	matches := 0
+	for _, s := range selectors {
+		sub, ok := s.(map[string]interface{})
+		if !ok {
+			return false, fmt.Errorf("%s expects a list of selectors", operator)
+		}
+		match, err := matchSelector(sub, doc)
+		if err != nil {
+			return false, err
+		}
+		if match {
+			matches++
+		}
+	}
+
+	switch operator {
+	case "$and":
+		return matches == len(selectors), nil
+	case "$or":
+		return matches > 0, nil
+	default:
+		return matches == 0, nil
+	}
+}
+
+// matchCondition evaluates the condition on a field. A condition is either a value the
+// field must equal, a set of operators, or a selector on the fields of a nested object
+func matchCondition(condition interface{}, value interface{}, found bool) (bool, error) {
+	operators, ok := condition.(map[string]interface{})
+	if !ok {
+		return found && reflect.DeepEqual(condition, value), nil
+	}
+
+	for operator, argument := range operators {
+		if !strings.HasPrefix(operator, "$") {
+			// Nested field selector, e.g. {"address": {"city": "Paris"}}
+			if !found {
+				return false, nil
+			}
+			return matchNested(operators, value)
+		}
+
+		ok, err := matchOperator(operator, argument, value, found)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// matchNested evaluates a selector on the fields of a nested object
+func matchNested(selector map[string]interface{}, value interface{}) (bool, error) {
+	if _, ok := value.(map[string]interface{}); !ok {
+		return false, nil
+	}
+	return matchSelector(selector, value)
+}
+
+// matchOperator evaluates a single field operator
+func matchOperator(operator string, argument interface{}, value interface{}, found bool) (bool, error) {
+	switch operator {
+	case "$exists":
+		want, ok := argument.(bool)
+		if !ok {
+			return false, fmt.Errorf("$exists expects a boolean")
+		}
+		return found == want, nil
+	case "$eq":
+		return found && reflect.DeepEqual(argument, value), nil
+	case "$ne":
+		return !found || !reflect.DeepEqual(argument, value), nil
+	case "$gt", "$gte", "$lt", "$lte":
+		if !found {
+			return false, nil
+		}
+		cmp, ok := compare(value, argument)
+		if !ok {
+			return false, nil
+		}
+		switch operator {
+		case "$gt":
+			return cmp > 0, nil
+		case "$gte":
+			return cmp >= 0, nil
+		case "$lt":
+			return cmp < 0, nil
+		default:
+			return cmp <= 0, nil
+		}
+	case "$in", "$nin":
+		list, ok := argument.([]interface{})
+		if !ok {
+			return false, fmt.Errorf("%s expects a list", operator)
+		}
+		in := false
+		for _, candidate := range list {
+			if found && reflect.DeepEqual(candidate, value) {
+				in = true
+				break
+			}
+		}
+		if operator == "$in" {
+			return in, nil
+		}
+		return found && !in, nil
+	case "$regex":
+		pattern, ok := argument.(string)
+		if !ok {
+			return false, fmt.Errorf("$regex expects a string")
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return false, fmt.Errorf("invalid $regex %q: %v", pattern, err)
+		}
+		s, isString := value.(string)
+		return found && isString && re.MatchString(s), nil
+	case "$size":
+		size, ok := argument.(float64)
+		if !ok {
+			return false, fmt.Errorf("$size expects a number")
+		}
+		list, isList := value.([]interface{})
+		return found && isList && float64(len(list)) == size, nil
+	case "$elemMatch":
+		list, isList := value.([]interface{})
+		if !found || !isList {
+			return false, nil
+		}
+		for _, element := range list {
+			ok, err := matchCondition(argument, element, true)
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+	case "$not":
+		ok, err := matchCondition(argument, value, found)
+		return !ok, err
+	default:
+		return false, fmt.Errorf("operator %s is not supported", operator)
+	}
+}
+
+// compare orders two values of the same JSON type, only numbers and strings are comparable
+func compare(a, b interface{}) (int, bool) {
+	switch x := a.(type) {
+	case float64:
+		y, ok := b.(float64)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		}
+		return 0, true
+	case string:
+		y, ok := b.(string)
+		if !ok {
+			return 0, false
+		}
+		return strings.Compare(x, y), true
+	}
+	return 0, false
+}
+
+// lookup returns the value of a dotted field path in a document
+func lookup(doc interface{}, path string) (interface{}, bool) {
+	value := doc
+	for _, field := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		value, ok = object[field]
+		if !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
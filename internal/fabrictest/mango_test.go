@@ -0,0 +1,59 @@
+package fabrictest
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRichQuery(t *testing.T) {
+	values := map[string][]byte{
+		"a": []byte(`{"kind":"fruit","name":"apple","price":3,"tags":["red","sweet"],"origin":{"country":"FR"}}`),
+		"b": []byte(`{"kind":"fruit","name":"banana","price":1.5,"tags":["yellow"]}`),
+		"c": []byte(`{"kind":"vegetable","name":"carrot","price":2}`),
+		"d": []byte(`not json`),
+	}
+
+	tests := []struct {
+		selector string
+		want     string
+	}{
+		{`{}`, "a,b,c"},
+		{`{"kind":"fruit"}`, "a,b"},
+		{`{"price":{"$gte":2}}`, "a,c"},
+		{`{"price":{"$gt":1,"$lt":3}}`, "b,c"},
+		{`{"name":{"$in":["apple","carrot"]}}`, "a,c"},
+		{`{"name":{"$nin":["apple","carrot"]}}`, "b"},
+		{`{"name":{"$regex":"^b"}}`, "b"},
+		{`{"origin.country":"FR"}`, "a"},
+		{`{"origin":{"country":"FR"}}`, "a"},
+		{`{"origin":{"$exists":false}}`, "b,c"},
+		{`{"tags":{"$size":1}}`, "b"},
+		{`{"tags":{"$elemMatch":{"$eq":"sweet"}}}`, "a"},
+		{`{"$or":[{"name":"apple"},{"price":2}]}`, "a,c"},
+		{`{"$and":[{"kind":"fruit"},{"price":{"$ne":3}}]}`, "b"},
+		{`{"$nor":[{"kind":"fruit"}]}`, "c"},
+		{`{"$not":{"kind":"fruit"}}`, "c"},
+		{`{"name":{"$not":{"$eq":"apple"}}}`, "b,c"},
+	}
+
+	for _, tt := range tests {
+		results, err := richQuery(values, `{"selector":`+tt.selector+`}`)
+		if err != nil {
+			t.Errorf("%s: %v", tt.selector, err)
+			continue
+		}
+		var keys []string
+		for _, kv := range results {
+			keys = append(keys, kv.Key)
+		}
+		if got := strings.Join(keys, ","); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.selector, got, tt.want)
+		}
+	}
+
+	for _, query := range []string{`{}`, `not json`, `{"selector":{"name":{"$foo":1}}}`} {
+		if _, err := richQuery(values, query); err == nil {
+			t.Errorf("query %s was accepted", query)
+		}
+	}
+}
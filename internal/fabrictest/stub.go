@@ -0,0 +1,502 @@
+// Package fabrictest provides in-memory implementations of the Fabric chaincode stub
+// and client identity so that contract functions can be unit tested without a network.
+package fabrictest
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	compositeKeyNamespace = "\x00"
+	maxUnicodeRune        = string(utf8.MaxRune)
+)
+
+// Event is a chaincode event set by a committed transaction
+type Event struct {
+	TxID    string
+	Name    string
+	Payload []byte
+}
+
+// write is a pending world state or private data write, a nil value is a delete
+type write struct {
+	value []byte
+}
+
+// Stub is an in-memory shim.ChaincodeStubInterface. Like a peer, it only exposes
+// committed state to reads: writes made by a transaction become visible once the
+// transaction is committed with Commit, and are discarded by Rollback
+type Stub struct {
+	ChannelID string
+
+	state      map[string][]byte
+	history    map[string][]*queryresult.KeyModification
+	private    map[string]map[string][]byte
+	validation map[string][]byte
+	events     []Event
+
+	txID        string
+	txTimestamp time.Time
+	function    string
+	args        []string
+	transient   map[string][]byte
+	writes      map[string]write
+	privWrites  map[string]map[string]write
+	event       *Event
+}
+
+var _ shim.ChaincodeStubInterface = (*Stub)(nil)
+
+// NewStub returns an empty stub on the given channel
+func NewStub(channelID string) *Stub {
+	return &Stub{
+		ChannelID:  channelID,
+		state:      map[string][]byte{},
+		history:    map[string][]*queryresult.KeyModification{},
+		private:    map[string]map[string][]byte{},
+		validation: map[string][]byte{},
+	}
+}
+
+// StartTransaction begins a transaction invoking the given function with the given
+// arguments at the given proposal time. Pending writes of a previous transaction
+// that was neither committed nor rolled back are discarded
+func (s *Stub) StartTransaction(txID string, timestamp time.Time, function string, args ...string) {
+	s.txID = txID
+	s.txTimestamp = timestamp
+	s.function = function
+	s.args = args
+	s.transient = nil
+	s.writes = map[string]write{}
+	s.privWrites = map[string]map[string]write{}
+	s.event = nil
+}
+
+// Commit applies the writes of the current transaction, records them in the key
+// history and publishes the transaction event
+func (s *Stub) Commit() {
+	for key, w := range s.writes {
+		if w.value == nil {
+			delete(s.state, key)
+		} else {
+			s.state[key] = w.value
+		}
+		s.history[key] = append(s.history[key], &queryresult.KeyModification{
+			TxId:      s.txID,
+			Value:     w.value,
+			Timestamp: timestamppb.New(s.txTimestamp),
+			IsDelete:  w.value == nil,
+		})
+	}
+
+	for collection, writes := range s.privWrites {
+		if s.private[collection] == nil {
+			s.private[collection] = map[string][]byte{}
+		}
+		for key, w := range writes {
+			if w.value == nil {
+				delete(s.private[collection], key)
+			} else {
+				s.private[collection][key] = w.value
+			}
+		}
+	}
+
+	if s.event != nil {
+		s.events = append(s.events, *s.event)
+	}
+
+	s.Rollback()
+}
+
+// Rollback discards the writes and event of the current transaction
+func (s *Stub) Rollback() {
+	s.writes = map[string]write{}
+	s.privWrites = map[string]map[string]write{}
+	s.event = nil
+}
+
+// SetTransient sets the transient data of the current transaction
+func (s *Stub) SetTransient(transient map[string][]byte) {
+	s.transient = transient
+}
+
+// Events returns the events of the committed transactions, oldest first
+func (s *Stub) Events() []Event {
+	return s.events
+}
+
+// LastEvent returns the event of the last committed transaction that set one
+func (s *Stub) LastEvent() (Event, bool) {
+	if len(s.events) == 0 {
+		return Event{}, false
+	}
+	return s.events[len(s.events)-1], true
+}
+
+// PutCommittedState writes a value directly to the committed world state, bypassing
+// transactions. It is meant to seed state or to simulate tampering in tests
+func (s *Stub) PutCommittedState(key string, value []byte) {
+	s.state[key] = value
+}
+
+// CommittedState returns a value of the committed world state
+func (s *Stub) CommittedState(key string) []byte {
+	return s.state[key]
+}
+
+// GetArgs returns the function name and arguments of the current transaction as bytes
+func (s *Stub) GetArgs() [][]byte {
+	args := [][]byte{[]byte(s.function)}
+	for _, arg := range s.args {
+		args = append(args, []byte(arg))
+	}
+	return args
+}
+
+// GetStringArgs returns the function name and arguments of the current transaction
+func (s *Stub) GetStringArgs() []string {
+	return append([]string{s.function}, s.args...)
+}
+
+// GetFunctionAndParameters returns the function name and arguments of the current transaction
+func (s *Stub) GetFunctionAndParameters() (string, []string) {
+	return s.function, s.args
+}
+
+// GetArgsSlice returns the concatenated arguments of the current transaction
+func (s *Stub) GetArgsSlice() ([]byte, error) {
+	var slice []byte
+	for _, arg := range s.GetArgs() {
+		slice = append(slice, arg...)
+	}
+	return slice, nil
+}
+
+// GetTxID returns the ID of the current transaction
+func (s *Stub) GetTxID() string {
+	return s.txID
+}
+
+// GetChannelID returns the channel of the stub
+func (s *Stub) GetChannelID() string {
+	return s.ChannelID
+}
+
+// InvokeChaincode is not supported and always returns an error response
+func (s *Stub) InvokeChaincode(chaincodeName string, args [][]byte, channel string) pb.Response {
+	return shim.Error(fmt.Sprintf("chaincode to chaincode invocation of %s is not supported", chaincodeName))
+}
+
+// GetState returns the committed value of a key
+func (s *Stub) GetState(key string) ([]byte, error) {
+	return s.state[key], nil
+}
+
+// PutState records a write of a key in the current transaction
+func (s *Stub) PutState(key string, value []byte) error {
+	if key == "" {
+		return fmt.Errorf("key must not be an empty string")
+	}
+	if value == nil {
+		value = []byte{}
+	}
+	s.writes[key] = write{value: value}
+	return nil
+}
+
+// DelState records a delete of a key in the current transaction
+func (s *Stub) DelState(key string) error {
+	s.writes[key] = write{}
+	return nil
+}
+
+// SetStateValidationParameter sets the key level endorsement policy of a key
+func (s *Stub) SetStateValidationParameter(key string, ep []byte) error {
+	s.validation[key] = ep
+	return nil
+}
+
+// GetStateValidationParameter returns the key level endorsement policy of a key
+func (s *Stub) GetStateValidationParameter(key string) ([]byte, error) {
+	return s.validation[key], nil
+}
+
+// GetStateByRange iterates over the committed simple keys in [startKey, endKey)
+func (s *Stub) GetStateByRange(startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+	if strings.HasPrefix(startKey, compositeKeyNamespace) || strings.HasPrefix(endKey, compositeKeyNamespace) {
+		return nil, fmt.Errorf("range query keys must not start with a null character")
+	}
+	if startKey == "" {
+		// Simple keys sort after the composite key namespace
+		startKey = "\x01"
+	}
+	if endKey == "" {
+		endKey = maxUnicodeRune
+	}
+	return newStateIterator(s.scan(startKey, endKey)), nil
+}
+
+// GetStateByRangeWithPagination iterates over a page of the committed simple keys in
+// [startKey, endKey), resuming at the bookmark returned with the previous page
+func (s *Stub) GetStateByRangeWithPagination(startKey, endKey string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	if strings.HasPrefix(startKey, compositeKeyNamespace) || strings.HasPrefix(endKey, compositeKeyNamespace) {
+		return nil, nil, fmt.Errorf("range query keys must not start with a null character")
+	}
+	if startKey == "" {
+		startKey = "\x01"
+	}
+	if endKey == "" {
+		endKey = maxUnicodeRune
+	}
+	results, metadata := paginate(s.scan(startKey, endKey), pageSize, bookmark)
+	return newStateIterator(results), metadata, nil
+}
+
+// GetStateByPartialCompositeKey iterates over the committed composite keys starting with the given attributes
+func (s *Stub) GetStateByPartialCompositeKey(objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+	prefix, err := shim.CreateCompositeKey(objectType, keys)
+	if err != nil {
+		return nil, err
+	}
+	return newStateIterator(s.scan(prefix, prefix+maxUnicodeRune)), nil
+}
+
+// GetStateByPartialCompositeKeyWithPagination iterates over a page of the committed
+// composite keys starting with the given attributes, resuming at the bookmark returned
+// with the previous page
+func (s *Stub) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	prefix, err := shim.CreateCompositeKey(objectType, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	results, metadata := paginate(s.scan(prefix, prefix+maxUnicodeRune), pageSize, bookmark)
+	return newStateIterator(results), metadata, nil
+}
+
+// CreateCompositeKey combines the object type and attributes into a composite key
+func (s *Stub) CreateCompositeKey(objectType string, attributes []string) (string, error) {
+	return shim.CreateCompositeKey(objectType, attributes)
+}
+
+// SplitCompositeKey splits a composite key into its object type and attributes
+func (s *Stub) SplitCompositeKey(compositeKey string) (string, []string, error) {
+	if !strings.HasPrefix(compositeKey, compositeKeyNamespace) {
+		return "", nil, fmt.Errorf("%q is not a composite key", compositeKey)
+	}
+	components := strings.Split(strings.TrimSuffix(compositeKey[1:], "\x00"), "\x00")
+	return components[0], components[1:], nil
+}
+
+// GetQueryResult iterates over the committed JSON documents matching the selector of a
+// CouchDB query, see richQuery for the supported subset
+func (s *Stub) GetQueryResult(query string) (shim.StateQueryIteratorInterface, error) {
+	results, err := richQuery(s.state, query)
+	if err != nil {
+		return nil, err
+	}
+	return newStateIterator(results), nil
+}
+
+// GetQueryResultWithPagination iterates over a page of the committed JSON documents
+// matching the selector of a CouchDB query, resuming at the bookmark returned with the
+// previous page
+func (s *Stub) GetQueryResultWithPagination(query string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	results, err := richQuery(s.state, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	page, metadata := paginate(results, pageSize, bookmark)
+	return newStateIterator(page), metadata, nil
+}
+
+// GetHistoryForKey iterates over the committed modifications of a key, newest first
+func (s *Stub) GetHistoryForKey(key string) (shim.HistoryQueryIteratorInterface, error) {
+	modifications := s.history[key]
+	reversed := make([]*queryresult.KeyModification, 0, len(modifications))
+	for i := len(modifications) - 1; i >= 0; i-- {
+		reversed = append(reversed, modifications[i])
+	}
+	return &historyIterator{modifications: reversed}, nil
+}
+
+// GetPrivateData returns the committed value of a key in a private data collection
+func (s *Stub) GetPrivateData(collection, key string) ([]byte, error) {
+	if collection == "" {
+		return nil, fmt.Errorf("collection must not be an empty string")
+	}
+	return s.private[collection][key], nil
+}
+
+// GetPrivateDataHash returns the SHA-256 of the committed value of a key in a private data collection
+func (s *Stub) GetPrivateDataHash(collection, key string) ([]byte, error) {
+	value, err := s.GetPrivateData(collection, key)
+	if err != nil || value == nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(value)
+	return sum[:], nil
+}
+
+// PutPrivateData records a write of a key in a private data collection
+func (s *Stub) PutPrivateData(collection string, key string, value []byte) error {
+	if collection == "" {
+		return fmt.Errorf("collection must not be an empty string")
+	}
+	if value == nil {
+		value = []byte{}
+	}
+	s.privateWrites(collection)[key] = write{value: value}
+	return nil
+}
+
+// DelPrivateData records a delete of a key in a private data collection
+func (s *Stub) DelPrivateData(collection, key string) error {
+	if collection == "" {
+		return fmt.Errorf("collection must not be an empty string")
+	}
+	s.privateWrites(collection)[key] = write{}
+	return nil
+}
+
+// PurgePrivateData records a delete of a key in a private data collection
+func (s *Stub) PurgePrivateData(collection, key string) error {
+	return s.DelPrivateData(collection, key)
+}
+
+// SetPrivateDataValidationParameter sets the key level endorsement policy of a private key
+func (s *Stub) SetPrivateDataValidationParameter(collection, key string, ep []byte) error {
+	s.validation[collection+"\x00"+key] = ep
+	return nil
+}
+
+// GetPrivateDataValidationParameter returns the key level endorsement policy of a private key
+func (s *Stub) GetPrivateDataValidationParameter(collection, key string) ([]byte, error) {
+	return s.validation[collection+"\x00"+key], nil
+}
+
+// GetPrivateDataByRange iterates over the committed keys of a collection in [startKey, endKey)
+func (s *Stub) GetPrivateDataByRange(collection, startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+	if endKey == "" {
+		endKey = maxUnicodeRune
+	}
+	return newStateIterator(scan(s.private[collection], startKey, endKey)), nil
+}
+
+// GetPrivateDataByPartialCompositeKey iterates over the committed composite keys of a
+// collection starting with the given attributes
+func (s *Stub) GetPrivateDataByPartialCompositeKey(collection, objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+	prefix, err := shim.CreateCompositeKey(objectType, keys)
+	if err != nil {
+		return nil, err
+	}
+	return newStateIterator(scan(s.private[collection], prefix, prefix+maxUnicodeRune)), nil
+}
+
+// GetPrivateDataQueryResult iterates over the committed JSON documents of a collection
+// matching the selector of a CouchDB query
+func (s *Stub) GetPrivateDataQueryResult(collection, query string) (shim.StateQueryIteratorInterface, error) {
+	results, err := richQuery(s.private[collection], query)
+	if err != nil {
+		return nil, err
+	}
+	return newStateIterator(results), nil
+}
+
+// GetCreator returns no creator, tests set the client identity directly
+func (s *Stub) GetCreator() ([]byte, error) {
+	return nil, nil
+}
+
+// GetTransient returns the transient data of the current transaction
+func (s *Stub) GetTransient() (map[string][]byte, error) {
+	return s.transient, nil
+}
+
+// GetBinding returns no binding
+func (s *Stub) GetBinding() ([]byte, error) {
+	return nil, nil
+}
+
+// GetDecorations returns no decorations
+func (s *Stub) GetDecorations() map[string][]byte {
+	return nil
+}
+
+// GetSignedProposal returns no proposal
+func (s *Stub) GetSignedProposal() (*pb.SignedProposal, error) {
+	return nil, nil
+}
+
+// GetTxTimestamp returns the proposal time of the current transaction
+func (s *Stub) GetTxTimestamp() (*timestamppb.Timestamp, error) {
+	return timestamppb.New(s.txTimestamp), nil
+}
+
+// SetEvent sets the event of the current transaction, replacing any previous one
+func (s *Stub) SetEvent(name string, payload []byte) error {
+	if name == "" {
+		return fmt.Errorf("event name can not be empty string")
+	}
+	s.event = &Event{TxID: s.txID, Name: name, Payload: payload}
+	return nil
+}
+
+func (s *Stub) privateWrites(collection string) map[string]write {
+	if s.privWrites[collection] == nil {
+		s.privWrites[collection] = map[string]write{}
+	}
+	return s.privWrites[collection]
+}
+
+func (s *Stub) scan(startKey, endKey string) []*queryresult.KV {
+	return scan(s.state, startKey, endKey)
+}
+
+// scan returns the entries of a key space in [startKey, endKey) ordered by key
+func scan(values map[string][]byte, startKey, endKey string) []*queryresult.KV {
+	var keys []string
+	for key := range values {
+		if key >= startKey && key < endKey {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	results := make([]*queryresult.KV, 0, len(keys))
+	for _, key := range keys {
+		results = append(results, &queryresult.KV{Key: key, Value: values[key]})
+	}
+	return results
+}
+
+// paginate returns the page of results starting at the bookmark key, or at the first
+// result when the bookmark is empty. The bookmark of the next page is the key of the
+// first result after the page, or empty when there are no more results
+func paginate(results []*queryresult.KV, pageSize int32, bookmark string) ([]*queryresult.KV, *pb.QueryResponseMetadata) {
+	start := 0
+	if bookmark != "" {
+		start = sort.Search(len(results), func(i int) bool { return results[i].Key >= bookmark })
+	}
+	end := len(results)
+	if pageSize > 0 && start+int(pageSize) < end {
+		end = start + int(pageSize)
+	}
+
+	next := ""
+	if end < len(results) {
+		next = results[end].Key
+	}
+	page := results[start:end]
+	return page, &pb.QueryResponseMetadata{FetchedRecordsCount: int32(len(page)), Bookmark: next}
+}
@@ -0,0 +1,77 @@
+package fabrictest
+
+import (
+	"testing"
+	"time"
+)
+
+var txTime = time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+func TestStubCommit(t *testing.T) {
+	stub := NewStub("mychannel")
+
+	stub.StartTransaction("tx1", txTime, "Put")
+	if err := stub.PutState("a", []byte("1")); err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := stub.GetState("a"); value != nil {
+		t.Errorf("uncommitted write is visible: %s", value)
+	}
+	stub.Commit()
+
+	stub.StartTransaction("tx2", txTime.Add(time.Minute), "Put")
+	if value, _ := stub.GetState("a"); string(value) != "1" {
+		t.Errorf("got %q, want committed value 1", value)
+	}
+	_ = stub.PutState("a", []byte("2"))
+	_ = stub.SetEvent("Changed", nil)
+	stub.Rollback()
+
+	if value := stub.CommittedState("a"); string(value) != "1" {
+		t.Errorf("rolled back write was applied: %s", value)
+	}
+	if _, ok := stub.LastEvent(); ok {
+		t.Error("event of a rolled back transaction was published")
+	}
+
+	stub.StartTransaction("tx3", txTime.Add(2*time.Minute), "Delete")
+	_ = stub.DelState("a")
+	stub.Commit()
+
+	iterator, _ := stub.GetHistoryForKey("a")
+	var txIDs []string
+	for iterator.HasNext() {
+		modification, _ := iterator.Next()
+		txIDs = append(txIDs, modification.TxId)
+	}
+	if len(txIDs) != 2 || txIDs[0] != "tx3" || txIDs[1] != "tx1" {
+		t.Errorf("got history %v, want [tx3 tx1]", txIDs)
+	}
+}
+
+func TestStubRangeExcludesCompositeKeys(t *testing.T) {
+	stub := NewStub("mychannel")
+	key, _ := stub.CreateCompositeKey("order", []string{"ORD-1"})
+	stub.PutCommittedState(key, []byte("{}"))
+	stub.PutCommittedState("ORD-2", []byte("{}"))
+
+	iterator, _ := stub.GetStateByRange("", "")
+	var keys []string
+	for iterator.HasNext() {
+		kv, _ := iterator.Next()
+		keys = append(keys, kv.Key)
+	}
+	if len(keys) != 1 || keys[0] != "ORD-2" {
+		t.Errorf("got keys %q, want [ORD-2]", keys)
+	}
+
+	iterator, _ = stub.GetStateByPartialCompositeKey("order", nil)
+	if !iterator.HasNext() {
+		t.Fatal("composite key query returned nothing")
+	}
+	kv, _ := iterator.Next()
+	objectType, attributes, _ := stub.SplitCompositeKey(kv.Key)
+	if objectType != "order" || len(attributes) != 1 || attributes[0] != "ORD-1" {
+		t.Errorf("got %s %v, want order [ORD-1]", objectType, attributes)
+	}
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"log"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/ravinayag/Chaincode-supplychain-Audit-trail/ordermanagement"
+)
+
+// main starts the chaincode for in-peer deployment, where the peer launches the
+// chaincode process. See chaincode-external for the chaincode-as-a-service build
+func main() {
+	chaincode, err := contractapi.NewChaincode(ordermanagement.NewSmartContract())
+	if err != nil {
+		log.Panicf("error creating logistics chaincode: %s", err)
+	}
+
+	if err := chaincode.Start(); err != nil {
+		log.Panicf("error starting logistics chaincode: %s", err)
+	}
+}
@@ -0,0 +1,224 @@
+package ordermanagement
+
+import (
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// roleAttribute is the certificate attribute holding the role of the caller
+const roleAttribute = "role"
+
+// Roles a caller can hold through the role certificate attribute
+const (
+	RoleAdmin   = "admin"
+	RoleBuyer   = "buyer"
+	RoleSeller  = "seller"
+	RoleCarrier = "carrier"
+	RoleAuditor = "auditor"
+	RoleBank    = "bank"
+)
+
+// Environment variables listing, comma separated, the MSP IDs of the organizations whose
+// members may hold the admin, bank and carrier roles
+const (
+	adminMSPIDsEnv   = "CHAINCODE_ADMIN_MSPIDS"
+	bankMSPIDsEnv    = "CHAINCODE_BANK_MSPIDS"
+	carrierMSPIDsEnv = "CHAINCODE_CARRIER_MSPIDS"
+)
+
+// roleMSPIDs binds roles to the organizations allowed to hold them, whatever the function
+// called. A role attribute is issued by the CA of each organization, so without it any
+// organization of the channel could make its members admins, banks or carriers. The
+// organizations are read once when the chaincode starts. The other roles may be held in
+// any organization
+var roleMSPIDs = map[string][]string{
+	RoleAdmin:   mspIDsFromEnv(adminMSPIDsEnv, "Org1MSP"),
+	RoleBank:    mspIDsFromEnv(bankMSPIDsEnv, "Org2MSP"),
+	RoleCarrier: mspIDsFromEnv(carrierMSPIDsEnv, "Org2MSP"),
+}
+
+// mspIDsFromEnv returns the MSP IDs listed in an environment variable, or the default
+// ones when it is unset or empty
+func mspIDsFromEnv(env string, defaults ...string) []string {
+	var mspIDs []string
+	for _, mspID := range strings.Split(os.Getenv(env), ",") {
+		if mspID = strings.TrimSpace(mspID); mspID != "" {
+			mspIDs = append(mspIDs, mspID)
+		}
+	}
+	if len(mspIDs) == 0 {
+		return defaults
+	}
+	return mspIDs
+}
+
+// readRoles are the roles allowed to call the query functions
+var readRoles = []string{RoleAdmin, RoleBuyer, RoleSeller, RoleCarrier, RoleAuditor, RoleBank}
+
+// accessPolicy describes who may call a contract function. The caller must hold one of
+// the roles and, when MSP IDs are listed, belong to one of those organizations. The
+// organizations of roleMSPIDs apply on top
+type accessPolicy struct {
+	roles  []string
+	mspIDs []string
+}
+
+// accessPolicies declares the access policy of every contract function. Functions
+// missing from the table cannot be called by anyone
+var accessPolicies = map[string]accessPolicy{
+	"InitLedger":             {roles: []string{RoleAdmin}},
+	"InitOrder":              {roles: []string{RoleAdmin}},
+	"InitShipEngine":         {roles: []string{RoleAdmin}},
+	"MigrateToCompositeKeys": {roles: []string{RoleAdmin}},
+	"RegisterPaymentRail":    {roles: []string{RoleAdmin}},
+	"SetPaymentRailEnabled":  {roles: []string{RoleAdmin}},
+	"SetMaxBatchSize":        {roles: []string{RoleAdmin}},
+
+	"GetAuditTrail":    {roles: readRoles},
+	"VerifyAuditChain": {roles: readRoles},
+
+	"CreateOrder":    {roles: []string{RoleSeller}},
+	"UpdateOrder":    {roles: []string{RoleSeller}},
+	"PatchOrder":     {roles: []string{RoleSeller}},
+	"DeleteOrder":    {roles: []string{RoleSeller}},
+	"ArchiveOrder":   {roles: []string{RoleSeller, RoleAdmin}},
+	"VoidOrder":      {roles: []string{RoleSeller, RoleAdmin}},
+	"RestoreOrder":   {roles: []string{RoleSeller, RoleAdmin}},
+	"ConfirmOrder":   {roles: []string{RoleSeller}},
+	"StartPacking":   {roles: []string{RoleSeller}},
+	"MarkPacked":     {roles: []string{RoleSeller}},
+	"MarkShipped":    {roles: []string{RoleSeller, RoleCarrier}},
+	"MarkInTransit":  {roles: []string{RoleCarrier}},
+	"MarkDelivered":  {roles: []string{RoleCarrier}},
+	"CancelOrder":    {roles: []string{RoleBuyer, RoleSeller}},
+	"ReturnOrder":    {roles: []string{RoleBuyer, RoleSeller}},
+	"AddLineItem":    {roles: []string{RoleSeller}},
+	"AmendLineItem":  {roles: []string{RoleSeller}},
+	"RemoveLineItem": {roles: []string{RoleSeller}},
+	"ReadOrder":      {roles: readRoles},
+	"OrderExists":    {roles: readRoles},
+	"GetAllOrders":   {roles: readRoles},
+	"GetOrderAsOf":   {roles: readRoles},
+	"GetOrdersAsOf":  {roles: readRoles},
+
+	"CreateOrdersBatch": {roles: []string{RoleSeller}},
+	"UpdateOrdersBatch": {roles: []string{RoleSeller}},
+	"GetBatchSettings":  {roles: readRoles},
+
+	"GetOrdersWithPagination":    {roles: readRoles},
+	"QueryOrders":                {roles: readRoles},
+	"QueryOrdersWithPagination":  {roles: readRoles},
+	"QueryOrdersByStatus":        {roles: readRoles},
+	"QueryOrdersByDateRange":     {roles: readRoles},
+	"QueryOrdersByPaymentMethod": {roles: readRoles},
+	"QueryOrdersByInvoice":       {roles: readRoles},
+
+	"GetHistoryForKey":   {roles: readRoles},
+	"GetOrderHistory":    {roles: readRoles},
+	"GetPaymentHistory":  {roles: readRoles},
+	"GetShipmentHistory": {roles: readRoles},
+
+	"GetOrderPayments":  {roles: readRoles},
+	"GetOrderShipments": {roles: readRoles},
+	"GetOrderDossier":   {roles: readRoles},
+
+	"CreateTransaction": {roles: []string{RoleBank}},
+	"AuthorizePayment":  {roles: []string{RoleBank}},
+	"CapturePayment":    {roles: []string{RoleBank}},
+	"SettlePayment":     {roles: []string{RoleBank}},
+	"RefundPayment":     {roles: []string{RoleBank}},
+	"RecordChargeback":  {roles: []string{RoleBank}},
+	"FailPayment":       {roles: []string{RoleBank}},
+	"GetTransaction":    {roles: readRoles},
+	"GetPaymentRail":    {roles: readRoles},
+	"GetPaymentRails":   {roles: readRoles},
+	"TransactionExists": {roles: readRoles},
+
+	"ReadOrderPrivateDetails":       {roles: []string{RoleAdmin, RoleBuyer, RoleSeller, RoleAuditor}},
+	"ReadTransactionPrivateDetails": {roles: []string{RoleAdmin, RoleBuyer, RoleSeller, RoleAuditor, RoleBank}},
+	"VerifyPrivateHash":             {roles: readRoles},
+
+	"CreateShipEngineData": {roles: []string{RoleCarrier}},
+	"AddTrackingEvent":     {roles: []string{RoleCarrier}},
+	"ShipEngineDataExists": {roles: readRoles},
+	"GetShipmentTimeline":  {roles: readRoles},
+}
+
+// AuthorizationError is returned when the caller is not allowed to call a function
+type AuthorizationError struct {
+	Function string
+	MSPID    string
+	Role     string
+	Reason   string
+}
+
+func (e *AuthorizationError) Error() string {
+	return e.contractError().Error()
+}
+
+func (e *AuthorizationError) contractError() *ContractError {
+	return newError(CodeForbidden, map[string]string{"function": e.Function, "mspId": e.MSPID, "role": e.Role},
+		"access denied to %s for role %q of %s: %s", e.Function, e.Role, e.MSPID, e.Reason)
+}
+
+// checkAccess is run before every contract function and rejects callers that do not
+// satisfy the access policy of the called function
+func checkAccess(ctx contractapi.TransactionContextInterface) error {
+	function, _ := ctx.GetStub().GetFunctionAndParameters()
+	return authorize(ctx, functionName(function))
+}
+
+// authorize checks the identity of the caller against the access policy of the function
+func authorize(ctx contractapi.TransactionContextInterface, function string) error {
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return internalError("failed to get MSP ID of the caller: %v", err)
+	}
+	role, _, err := ctx.GetClientIdentity().GetAttributeValue(roleAttribute)
+	if err != nil {
+		return internalError("failed to get %s attribute of the caller: %v", roleAttribute, err)
+	}
+
+	policy, ok := accessPolicies[function]
+	if !ok {
+		return &AuthorizationError{Function: function, MSPID: mspID, Role: role, Reason: "no access policy is defined for the function"}
+	}
+	if !contains(policy.roles, role) {
+		return &AuthorizationError{Function: function, MSPID: mspID, Role: role, Reason: "role is not allowed"}
+	}
+	if mspIDs, ok := roleMSPIDs[role]; ok && !contains(mspIDs, mspID) {
+		return &AuthorizationError{Function: function, MSPID: mspID, Role: role, Reason: "role is not held in the organization"}
+	}
+	if len(policy.mspIDs) > 0 && !contains(policy.mspIDs, mspID) {
+		return &AuthorizationError{Function: function, MSPID: mspID, Role: role, Reason: "organization is not allowed"}
+	}
+
+	return nil
+}
+
+// functionName strips the contract namespace from an invoked function name and
+// capitalizes it the same way the contract API does when dispatching
+func functionName(function string) string {
+	if i := strings.LastIndex(function, ":"); i != -1 {
+		function = function[i+1:]
+	}
+	if function == "" {
+		return function
+	}
+
+	runes := []rune(function)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,143 @@
+package ordermanagement
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/ravinayag/Chaincode-supplychain-Audit-trail/internal/fabrictest"
+)
+
+func TestAuthorize(t *testing.T) {
+	tests := []struct {
+		function string
+		role     string
+		allowed  bool
+	}{
+		{"CreateOrder", RoleSeller, true},
+		{"CreateOrder", RoleBuyer, false},
+		{"CreateOrder", RoleAuditor, false},
+		{"CreateShipEngineData", RoleCarrier, true},
+		{"CreateShipEngineData", RoleSeller, false},
+		{"CreateTransaction", RoleBank, true},
+		{"CreateTransaction", RoleSeller, false},
+		{"ReadOrder", RoleAuditor, true},
+		{"GetAllOrders", RoleAuditor, true},
+		{"DeleteOrder", RoleAuditor, false},
+		{"InitLedger", RoleSeller, false},
+		{"InitLedger", RoleAdmin, true},
+		{"ReadOrder", "", false},
+		{"NoSuchFunction", RoleAdmin, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.function+"/"+tt.role, func(t *testing.T) {
+			env := newTestEnv(t)
+			err := authorize(env.begin(tt.role, tt.function), tt.function)
+
+			if tt.allowed && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !tt.allowed {
+				var authErr *AuthorizationError
+				if !errors.As(err, &authErr) {
+					t.Fatalf("got error %v, want an AuthorizationError", err)
+				}
+				if authErr.Function != tt.function || authErr.Role != tt.role || authErr.MSPID != "Org1MSP" {
+					t.Errorf("unexpected authorization error: %+v", authErr)
+				}
+			}
+		})
+	}
+}
+
+func TestAuthorizeMSP(t *testing.T) {
+	original := accessPolicies["CreateOrder"]
+	accessPolicies["CreateOrder"] = accessPolicy{roles: []string{RoleSeller}, mspIDs: []string{"Org2MSP"}}
+	defer func() { accessPolicies["CreateOrder"] = original }()
+
+	env := newTestEnv(t)
+	ctx := env.begin(RoleSeller, "CreateOrder")
+	if err := authorize(ctx, "CreateOrder"); err == nil {
+		t.Error("a seller of Org1MSP was allowed where only Org2MSP is")
+	}
+
+	ctx.SetClientIdentity(fabrictest.NewClientIdentity("x509::CN=seller::CN=ca.org2.example.com", "Org2MSP", RoleSeller))
+	if err := authorize(ctx, "CreateOrder"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestAuthorizeRoleMSP(t *testing.T) {
+	tests := []struct {
+		function string
+		role     string
+		mspID    string
+		allowed  bool
+	}{
+		{"InitLedger", RoleAdmin, "Org1MSP", true},
+		{"InitLedger", RoleAdmin, "Org2MSP", false},
+		{"RegisterPaymentRail", RoleAdmin, "Org3MSP", false},
+		{"CreateTransaction", RoleBank, "Org2MSP", true},
+		{"CreateTransaction", RoleBank, "Org1MSP", false},
+		{"CreateShipEngineData", RoleCarrier, "Org2MSP", true},
+		{"MarkShipped", RoleCarrier, "Org1MSP", false},
+		{"MarkShipped", RoleSeller, "Org1MSP", true},
+		{"ReadOrder", RoleAuditor, "Org3MSP", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.function+"/"+tt.role+"/"+tt.mspID, func(t *testing.T) {
+			env := newTestEnv(t)
+			ctx := env.begin(tt.role, tt.function)
+			ctx.SetClientIdentity(fabrictest.NewClientIdentity("x509::CN="+tt.role+"::CN=ca.example.com", tt.mspID, tt.role))
+
+			err := authorize(ctx, tt.function)
+			if tt.allowed && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !tt.allowed && errorCode(err) != CodeForbidden {
+				t.Errorf("got error %v, want a FORBIDDEN error", err)
+			}
+		})
+	}
+}
+
+func TestMSPIDsFromEnv(t *testing.T) {
+	t.Setenv(bankMSPIDsEnv, " BankMSP, ,Org3MSP ")
+	if got := mspIDsFromEnv(bankMSPIDsEnv, "Org2MSP"); !reflect.DeepEqual(got, []string{"BankMSP", "Org3MSP"}) {
+		t.Errorf("got %v, want the listed MSP IDs", got)
+	}
+	t.Setenv(bankMSPIDsEnv, "")
+	if got := mspIDsFromEnv(bankMSPIDsEnv, "Org2MSP"); !reflect.DeepEqual(got, []string{"Org2MSP"}) {
+		t.Errorf("got %v, want the default MSP ID", got)
+	}
+}
+
+func TestCheckAccessFunctionName(t *testing.T) {
+	for _, function := range []string{"CreateOrder", "createOrder", "SmartContract:CreateOrder"} {
+		env := newTestEnv(t)
+		if err := checkAccess(env.begin(RoleSeller, function)); err != nil {
+			t.Errorf("checkAccess for %s: %v", function, err)
+		}
+		if err := checkAccess(env.begin(RoleBuyer, function)); err == nil {
+			t.Errorf("checkAccess for %s allowed a buyer", function)
+		}
+	}
+}
+
+func TestEveryFunctionHasAPolicy(t *testing.T) {
+	contractType := reflect.TypeOf(NewSmartContract())
+	ignored := reflect.TypeOf(&contractapi.Contract{})
+
+	for i := 0; i < contractType.NumMethod(); i++ {
+		name := contractType.Method(i).Name
+		if _, ok := ignored.MethodByName(name); ok {
+			continue
+		}
+		if _, ok := accessPolicies[name]; !ok {
+			t.Errorf("no access policy for %s", name)
+		}
+	}
+}
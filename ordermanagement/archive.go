@@ -0,0 +1,86 @@
+package ordermanagement
+
+import (
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// archivedStatuses are the statuses of soft deleted orders. The listings leave these
+// orders out, QueryOrdersByStatus returns them
+var archivedStatuses = []interface{}{string(StatusArchived), string(StatusVoided)}
+
+// ArchiveOrder soft deletes an order that is no longer needed. The order stays on the
+// ledger with its payments and shipments, but leaves the listings and can no longer be
+// changed until it is restored with RestoreOrder. The reason is required
+func (s *SmartContract) ArchiveOrder(ctx contractapi.TransactionContextInterface, orderNo string, reason string) error {
+	return s.softDeleteOrder(ctx, orderNo, StatusArchived, EventOrderArchived, reason)
+}
+
+// VoidOrder soft deletes an order recorded in error, like ArchiveOrder
+func (s *SmartContract) VoidOrder(ctx contractapi.TransactionContextInterface, orderNo string, reason string) error {
+	return s.softDeleteOrder(ctx, orderNo, StatusVoided, EventOrderVoided, reason)
+}
+
+// softDeleteOrder moves an order in any status but the soft deleted ones to the given
+// soft deleted status. The transition records the status to restore
+func (s *SmartContract) softDeleteOrder(ctx contractapi.TransactionContextInterface, orderNo string, to OrderStatus, event string, reason string) error {
+	return s.moveOrder(ctx, orderNo, arg("reason", reason, required, maxLength(maxTextLength)), event, func(order *Order, from OrderStatus) (OrderStatus, error) {
+		if isArchived(from) {
+			return "", invalidTransition(orderObjectType, orderNo, string(from), string(to))
+		}
+		return to, nil
+	})
+}
+
+// RestoreOrder moves an archived or voided order back to the status it had before. The
+// reason is optional
+func (s *SmartContract) RestoreOrder(ctx contractapi.TransactionContextInterface, orderNo string, reason string) error {
+	return s.moveOrder(ctx, orderNo, textArg("reason", reason), EventOrderRestored, func(order *Order, from OrderStatus) (OrderStatus, error) {
+		if !isArchived(from) {
+			return "", newError(CodeInvalidStateTransition, map[string]string{"type": orderObjectType, "id": orderNo, "from": string(from)},
+				"the order %s is %s, only archived or voided orders can be restored", orderNo, from)
+		}
+		return order.statusBeforeArchival(), nil
+	})
+}
+
+// statusBeforeArchival returns the status the order had when it was soft deleted, or
+// Created when its transitions do not tell
+func (o *Order) statusBeforeArchival() OrderStatus {
+	for i := len(o.Transitions) - 1; i >= 0; i-- {
+		transition := o.Transitions[i]
+		if transition.To == o.Status && !isArchived(transition.From) {
+			return transition.From
+		}
+	}
+	return StatusCreated
+}
+
+// checkRetention enforces the retention rule: payments are kept for audit, so an order
+// with linked payments is never hard deleted, and neither is one with shipments since
+// their links would dangle. Such orders are archived instead
+func checkRetention(ctx contractapi.TransactionContextInterface, orderNo string) error {
+	for _, index := range []string{orderPaymentIndex, orderShipmentIndex} {
+		ids, err := linkedIDs(ctx, index, orderNo)
+		if err != nil {
+			return err
+		}
+		if len(ids) > 0 {
+			return newError(CodeConflict, map[string]string{"type": orderObjectType, "id": orderNo, "referencedBy": strings.Join(ids, ",")},
+				"the order %s cannot be deleted, it is referenced by %v, archive it instead", orderNo, ids)
+		}
+	}
+	return nil
+}
+
+// listedOrders removes the soft deleted orders from the results of a listing
+func listedOrders(results []QueryResult) []QueryResult {
+	listed := results[:0]
+	for _, result := range results {
+		if !isArchived(result.Record.lifecycleStatus()) {
+			listed = append(listed, result)
+		}
+	}
+	return listed
+}
@@ -0,0 +1,161 @@
+package ordermanagement
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// archiveOrder archives an order as a seller
+func (e *testEnv) archiveOrder(orderNo string, reason string) error {
+	return e.submit(RoleSeller, "ArchiveOrder", func(ctx contractapi.TransactionContextInterface) error {
+		return e.contract.ArchiveOrder(ctx, orderNo, reason)
+	})
+}
+
+// restoreOrder restores an order as an admin
+func (e *testEnv) restoreOrder(orderNo string) error {
+	return e.submit(RoleAdmin, "RestoreOrder", func(ctx contractapi.TransactionContextInterface) error {
+		return e.contract.RestoreOrder(ctx, orderNo, "archived by mistake")
+	})
+}
+
+// listedOrderNos returns the order numbers listed by GetAllOrders
+func (e *testEnv) listedOrderNos() []string {
+	e.t.Helper()
+	var orderNos []string
+	e.mustSubmit(RoleAuditor, "GetAllOrders", func(ctx contractapi.TransactionContextInterface) error {
+		results, err := e.contract.GetAllOrders(ctx)
+		for _, result := range results {
+			orderNos = append(orderNos, result.Key)
+		}
+		return err
+	})
+	return orderNos
+}
+
+func TestArchiveAndRestoreOrder(t *testing.T) {
+	env := newTestEnv(t)
+	env.createOrder("ORD-1")
+	env.createOrder("ORD-2")
+	if err := confirm.run(env, "ORD-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := env.archiveOrder("ORD-1", "duplicate of ORD-2"); err != nil {
+		t.Fatal(err)
+	}
+	order := env.readOrder("ORD-1")
+	last := order.Transitions[len(order.Transitions)-1]
+	if order.Status != StatusArchived || last.From != StatusConfirmed || last.Reason != "duplicate of ORD-2" || last.Actor == "" || last.MSPID != "Org1MSP" {
+		t.Errorf("unexpected archived order: status %s, transition %+v", order.Status, last)
+	}
+	if event := env.lastEvent(); event.Name != EventOrderArchived {
+		t.Errorf("event = %s, want %s", event.Name, EventOrderArchived)
+	}
+
+	// Archived orders leave the listings but are still returned by status
+	if orderNos := env.listedOrderNos(); len(orderNos) != 1 || orderNos[0] != "ORD-2" {
+		t.Errorf("listed orders %v, want only ORD-2", orderNos)
+	}
+	var results []QueryResult
+	env.mustSubmit(RoleAuditor, "QueryOrders", func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		results, err = env.contract.QueryOrders(ctx, `{"date":"2024-03-01"}`)
+		return err
+	})
+	if len(results) != 1 || results[0].Key != "ORD-2" {
+		t.Errorf("queried orders %v, want only ORD-2", results)
+	}
+	env.mustSubmit(RoleAuditor, "QueryOrdersByStatus", func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		results, err = env.contract.QueryOrdersByStatus(ctx, string(StatusArchived))
+		return err
+	})
+	if len(results) != 1 || results[0].Key != "ORD-1" {
+		t.Errorf("archived orders %v, want ORD-1", results)
+	}
+
+	// Archived orders can no longer be changed or referenced
+	if err := confirm.run(env, "ORD-1"); errorCode(err) != CodeInvalidStateTransition {
+		t.Errorf("moving an archived order: got %v", err)
+	}
+	if err := env.patchOrder("ORD-1", `{"orderDetail":"5 pallets"}`, order.Version); errorCode(err) != CodeInvalidStateTransition {
+		t.Errorf("patching an archived order: got %v", err)
+	}
+	err := env.submitPrivate(RoleBank, "CreateTransaction", paymentPrivate, func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.CreateTransaction(ctx, "PAY-1", "ORD-1", "ACH", "100.00", "USD", "", "deposit")
+	})
+	if errorCode(err) != CodeInvalidStateTransition {
+		t.Errorf("paying an archived order: got %v", err)
+	}
+	if err := env.archiveOrder("ORD-1", "again"); errorCode(err) != CodeInvalidStateTransition {
+		t.Errorf("archiving an archived order: got %v", err)
+	}
+
+	// Restoring brings back the status the order had
+	if err := env.restoreOrder("ORD-1"); err != nil {
+		t.Fatal(err)
+	}
+	if order := env.readOrder("ORD-1"); order.Status != StatusConfirmed || order.PackingStatus != "Pending" {
+		t.Errorf("restored order is %s, packing %s, want Confirmed", order.Status, order.PackingStatus)
+	}
+	if event := env.lastEvent(); event.Name != EventOrderRestored {
+		t.Errorf("event = %s, want %s", event.Name, EventOrderRestored)
+	}
+	if orderNos := env.listedOrderNos(); len(orderNos) != 2 {
+		t.Errorf("listed orders %v, want both orders", orderNos)
+	}
+	if err := env.restoreOrder("ORD-1"); errorCode(err) != CodeInvalidStateTransition {
+		t.Errorf("restoring an active order: got %v", err)
+	}
+}
+
+func TestVoidOrder(t *testing.T) {
+	env := newTestEnv(t)
+	env.createOrder("ORD-1")
+
+	err := env.submit(RoleSeller, "VoidOrder", func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.VoidOrder(ctx, "ORD-1", "")
+	})
+	if got := fieldRules(validationErrors(t, err)); got["reason"] != "required" {
+		t.Errorf("rejected fields = %v, want a required error on reason", got)
+	}
+
+	env.mustSubmit(RoleSeller, "VoidOrder", func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.VoidOrder(ctx, "ORD-1", "entered in error")
+	})
+	if order := env.readOrder("ORD-1"); order.Status != StatusVoided {
+		t.Errorf("status = %s, want %s", order.Status, StatusVoided)
+	}
+	if orderNos := env.listedOrderNos(); len(orderNos) != 0 {
+		t.Errorf("listed orders %v, want none", orderNos)
+	}
+	if err := env.restoreOrder("ORD-1"); err != nil {
+		t.Fatal(err)
+	}
+	if order := env.readOrder("ORD-1"); order.Status != StatusCreated {
+		t.Errorf("restored order is %s, want Created", order.Status)
+	}
+}
+
+func TestRetentionRule(t *testing.T) {
+	env := newTestEnv(t)
+	env.createOrder("ORD-1")
+	env.createPayment("PAY-1", "ORD-1")
+
+	err := env.submit(RoleSeller, "DeleteOrder", func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.DeleteOrder(ctx, "ORD-1")
+	})
+	if decoded := decodeError(t, err); decoded.Code != CodeConflict || decoded.Details["referencedBy"] != "PAY-1" {
+		t.Errorf("deleting an order with a payment: got %+v", decoded)
+	}
+
+	// The order is archived instead, which keeps it and its payment on the ledger
+	if err := env.archiveOrder("ORD-1", "retention"); err != nil {
+		t.Fatal(err)
+	}
+	if env.stub.CommittedState(orderKey(t, env, "ORD-1")) == nil {
+		t.Error("the archived order was removed from the world state")
+	}
+}
@@ -0,0 +1,257 @@
+package ordermanagement
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// OrderSnapshot is an order as it was at a past instant, reconstructed from the history
+// of its key. Existed is set when the order had been created and not deleted by then and
+// Deleted when it had been deleted, in which case Order is the last version before the
+// delete. TxID and Timestamp identify the transaction that wrote the version in effect
+type OrderSnapshot struct {
+	OrderNo   string `json:"orderNo"`
+	AsOf      string `json:"asOf"`
+	Existed   bool   `json:"existed"`
+	Deleted   bool   `json:"deleted"`
+	TxID      string `json:"txId,omitempty" metadata:",optional"`
+	Timestamp string `json:"timestamp,omitempty" metadata:",optional"`
+	Order     *Order `json:"order,omitempty" metadata:",optional"`
+}
+
+// GetOrderAsOf returns the order as it was at the given RFC3339 instant, including
+// whether it existed then or had already been deleted
+func (s *SmartContract) GetOrderAsOf(ctx contractapi.TransactionContextInterface, orderNo string, timestamp string) (*OrderSnapshot, error) {
+	logger := newLogger(ctx)
+
+	// Log the start of the function
+	logger.Debug("Reconstructing order", "orderNo", orderNo, "asOf", timestamp)
+
+	// Validate the arguments
+	err := validate(ctx,
+		idArg("orderNo", orderNo),
+		arg("timestamp", timestamp, required, rfc3339),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	asOf, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return nil, invalidArgument("the timestamp %q is not an RFC3339 timestamp", timestamp)
+	}
+
+	snapshot, found, err := orderAsOf(ctx, orderNo, asOf)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, notFound(orderObjectType, orderNo)
+	}
+
+	// Log the success of the operation
+	logger.Info("Order reconstructed", "orderNo", orderNo, "asOf", snapshot.AsOf, "existed", snapshot.Existed, "deleted", snapshot.Deleted)
+
+	return snapshot, nil
+}
+
+// maxAsOfPageSize is the largest number of orders a page of GetOrdersAsOf looks at. Each
+// of them is reconstructed from the history of its key, so pages are kept smaller than
+// those of the world state queries
+const maxAsOfPageSize = 100
+
+// PaginatedOrderSnapshots is a page of GetOrdersAsOf
+type PaginatedOrderSnapshots struct {
+	Records             []OrderSnapshot `json:"records"`
+	FetchedRecordsCount int32           `json:"fetchedRecordsCount"`
+	Bookmark            string          `json:"bookmark"`
+}
+
+// GetOrdersAsOf returns a page of the orders that existed at the given RFC3339 instant as
+// they were then, ordered by order number. Archived and voided orders are included. The
+// optional filter is a JSON object of the values the orders must have then, e.g.
+// {"status":"Shipped","total.currency":"EUR"}, naming nested fields by their path. The
+// status is the lifecycle status, derived for orders recorded before it was kept. A page
+// looks at the history of at most pageSize orders, at most 100. An empty bookmark returns
+// the first page, the bookmark of the result resumes after the returned page and is empty
+// once the last order has been looked at. Orders that did not exist then or do not match
+// the filter are left out, so a page may hold fewer orders than pageSize before the last one
+func (s *SmartContract) GetOrdersAsOf(ctx contractapi.TransactionContextInterface, timestamp string, filter string, pageSize int32, bookmark string) (*PaginatedOrderSnapshots, error) {
+	logger := newLogger(ctx)
+
+	// Log the start of the function
+	logger.Debug("Reconstructing orders", "asOf", timestamp, "filter", filter, "pageSize", pageSize, "bookmark", bookmark)
+
+	// Validate the arguments
+	err := validate(ctx,
+		arg("timestamp", timestamp, required, rfc3339),
+		textArg("filter", filter),
+		intArg("pageSize", int(pageSize), atLeast(1), atMost(maxAsOfPageSize)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	asOf, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return nil, invalidArgument("the timestamp %q is not an RFC3339 timestamp", timestamp)
+	}
+	wanted, err := parseSnapshotFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every order has an audit head from its first write on, which outlives the order
+	// when it is deleted, so a page of heads is a page of the orders ever recorded
+	headsIterator, metadata, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(auditHeadObjectType, []string{orderObjectType}, pageSize, bookmark)
+	if err != nil {
+		return nil, internalError("failed to read audit heads of orders: %v", err)
+	}
+	defer headsIterator.Close()
+
+	snapshots := []OrderSnapshot{}
+	for headsIterator.HasNext() {
+		queryResponse, err := headsIterator.Next()
+		if err != nil {
+			return nil, internalError("failed to read query results: %v", err)
+		}
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil || len(attributes) != 2 {
+			return nil, internalError("failed to split key %s: %v", queryResponse.Key, err)
+		}
+		orderNo := attributes[1]
+
+		snapshot, found, err := orderAsOf(ctx, orderNo, asOf)
+		if err != nil {
+			return nil, err
+		}
+		if !found || !snapshot.Existed {
+			continue
+		}
+
+		// Filter on the lifecycle status, which legacy orders only hold derived
+		order := *snapshot.Order
+		order.Status = order.lifecycleStatus()
+		orderJSON, err := json.Marshal(order)
+		if err != nil {
+			return nil, err
+		}
+		fields, err := flattenState(orderJSON)
+		if err != nil {
+			return nil, internalError("failed to decode order %s: %v", orderNo, err)
+		}
+		if matchesFilter(fields, wanted) {
+			snapshots = append(snapshots, *snapshot)
+		}
+	}
+
+	// Log the success of the operation
+	logger.Info("Orders reconstructed", "asOf", timestamp, "count", len(snapshots))
+
+	return &PaginatedOrderSnapshots{
+		Records:             snapshots,
+		FetchedRecordsCount: int32(len(snapshots)),
+		Bookmark:            metadata.Bookmark,
+	}, nil
+}
+
+// orderAsOf reconstructs an order at the given instant from the history of its key. The
+// version in effect is the last one committed at or before the instant. found is false
+// when the key has no history at all
+func orderAsOf(ctx contractapi.TransactionContextInterface, orderNo string, asOf time.Time) (*OrderSnapshot, bool, error) {
+	key, err := assetKey(ctx, orderObjectType, orderNo)
+	if err != nil {
+		return nil, false, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetHistoryForKey(key)
+	if err != nil {
+		return nil, false, internalError("failed to get history for order %s: %v", orderNo, err)
+	}
+	defer resultsIterator.Close()
+
+	snapshot := &OrderSnapshot{OrderNo: orderNo, AsOf: asOf.UTC().Format(time.RFC3339)}
+	found := false
+	var effective, lastWrite time.Time
+	var lastValue []byte
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, false, internalError("failed to read query results: %v", err)
+		}
+		found = true
+
+		committed := queryResponse.Timestamp.AsTime()
+
+		// Remember the newest value written before the instant for deleted orders
+		if !queryResponse.IsDelete && !committed.After(asOf) && (lastValue == nil || committed.After(lastWrite)) {
+			lastValue, lastWrite = queryResponse.Value, committed
+		}
+
+		// Versions come newest first, ties keep the first, newest one
+		if committed.After(asOf) || (snapshot.TxID != "" && !committed.After(effective)) {
+			continue
+		}
+		effective = committed
+		snapshot.TxID = queryResponse.TxId
+		snapshot.Timestamp = historyTimestamp(queryResponse.Timestamp)
+		snapshot.Existed = !queryResponse.IsDelete
+		snapshot.Deleted = queryResponse.IsDelete
+	}
+
+	if lastValue != nil {
+		snapshot.Order = &Order{}
+		err = json.Unmarshal(lastValue, snapshot.Order)
+		if err != nil {
+			return nil, false, internalError("failed to decode order %s: %v", orderNo, err)
+		}
+	}
+	return snapshot, found, nil
+}
+
+// parseSnapshotFilter decodes the filter of GetOrdersAsOf into the JSON encoded value
+// wanted for each field
+func parseSnapshotFilter(filter string) (map[string]string, error) {
+	wanted := map[string]string{}
+	if filter == "" {
+		return wanted, nil
+	}
+
+	decoded, err := decodeJSON([]byte(filter))
+	if err != nil {
+		return nil, invalidArgument("the filter is not valid JSON: %v", err)
+	}
+	object, ok := decoded.(map[string]interface{})
+	if !ok {
+		return nil, invalidArgument("the filter must be a JSON object")
+	}
+	for field, value := range object {
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			return nil, invalidArgument("the filter value of %s must be a string, number, boolean or null", field)
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, invalidArgument("invalid filter value of %s: %v", field, err)
+		}
+		wanted[field] = string(encoded)
+	}
+	return wanted, nil
+}
+
+// matchesFilter reports whether the flattened fields hold every wanted value. A null
+// value matches absent fields
+func matchesFilter(fields map[string]string, wanted map[string]string) bool {
+	for field, value := range wanted {
+		actual, ok := fields[field]
+		if !ok {
+			actual = "null"
+		}
+		if actual != value {
+			return false
+		}
+	}
+	return true
+}
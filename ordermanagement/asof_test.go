@@ -0,0 +1,191 @@
+package ordermanagement
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// asOfEnv creates ORD-1 at 10:00 and ORD-2 at 10:01, updates ORD-1 at 10:02 and deletes ORD-2 at 10:03
+func asOfEnv(t *testing.T) *testEnv {
+	env := newTestEnv(t)
+	env.createOrder("ORD-1")
+	env.createOrder("ORD-2")
+	env.mustSubmit(RoleSeller, "UpdateOrder", func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.UpdateOrder(ctx, "ORD-1", "2024-03-01", "12 pallets", "CreditCard")
+	})
+	env.mustSubmit(RoleSeller, "DeleteOrder", func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.DeleteOrder(ctx, "ORD-2")
+	})
+	return env
+}
+
+func TestGetOrderAsOf(t *testing.T) {
+	env := asOfEnv(t)
+
+	orderAsOf := func(orderNo string, timestamp string) (*OrderSnapshot, error) {
+		var snapshot *OrderSnapshot
+		err := env.submit(RoleAuditor, "GetOrderAsOf", func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			snapshot, err = env.contract.GetOrderAsOf(ctx, orderNo, timestamp)
+			return err
+		})
+		return snapshot, err
+	}
+
+	tests := []struct {
+		orderNo, timestamp string
+		existed, deleted   bool
+		txID, orderDetail  string
+	}{
+		{"ORD-1", "2024-03-01T09:59:00Z", false, false, "", ""},
+		{"ORD-1", "2024-03-01T10:01:30Z", true, false, "tx1", "10 pallets"},
+		{"ORD-1", "2024-03-01T10:02:00Z", true, false, "tx3", "12 pallets"},
+		{"ORD-2", "2024-03-01T12:02:00+02:00", true, false, "tx2", "10 pallets"},
+		{"ORD-2", "2024-03-02T00:00:00Z", false, true, "tx4", "10 pallets"},
+	}
+	for _, tt := range tests {
+		snapshot, err := orderAsOf(tt.orderNo, tt.timestamp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if snapshot.Existed != tt.existed || snapshot.Deleted != tt.deleted || snapshot.TxID != tt.txID {
+			t.Errorf("%s as of %s: existed %t, deleted %t, tx %s", tt.orderNo, tt.timestamp, snapshot.Existed, snapshot.Deleted, snapshot.TxID)
+		}
+		if tt.orderDetail == "" && snapshot.Order != nil {
+			t.Errorf("%s as of %s: got order %+v before it was created", tt.orderNo, tt.timestamp, snapshot.Order)
+		}
+		if tt.orderDetail != "" && (snapshot.Order == nil || snapshot.Order.OrderDetail != tt.orderDetail) {
+			t.Errorf("%s as of %s: got order %+v, want detail %s", tt.orderNo, tt.timestamp, snapshot.Order, tt.orderDetail)
+		}
+	}
+
+	if snapshot, _ := orderAsOf("ORD-2", "2024-03-01T12:02:00+02:00"); snapshot.AsOf != "2024-03-01T10:02:00Z" || snapshot.Timestamp != "2024-03-01T10:01:00Z" {
+		t.Errorf("snapshot as of %s written at %s, want UTC timestamps", snapshot.AsOf, snapshot.Timestamp)
+	}
+	if _, err := orderAsOf("ORD-3", "2024-03-01T10:00:00Z"); errorCode(err) != CodeNotFound {
+		t.Errorf("missing order: got %v", err)
+	}
+	_, err := orderAsOf("ORD-1", "2024-03-01")
+	if got := fieldRules(validationErrors(t, err)); got["timestamp"] != "timestamp" {
+		t.Errorf("rejected fields = %v, want a timestamp error on timestamp", got)
+	}
+}
+
+func TestGetOrdersAsOf(t *testing.T) {
+	env := asOfEnv(t)
+
+	ordersAsOf := func(timestamp string, filter string) ([]string, error) {
+		var orderNos []string
+		err := env.submit(RoleAuditor, "GetOrdersAsOf", func(ctx contractapi.TransactionContextInterface) error {
+			page, err := env.contract.GetOrdersAsOf(ctx, timestamp, filter, maxAsOfPageSize, "")
+			if err != nil {
+				return err
+			}
+			for _, snapshot := range page.Records {
+				orderNos = append(orderNos, snapshot.OrderNo+"@"+snapshot.TxID)
+			}
+			return nil
+		})
+		return orderNos, err
+	}
+
+	tests := []struct {
+		timestamp, filter string
+		want              []string
+	}{
+		{"2024-03-01T09:00:00Z", "", nil},
+		{"2024-03-01T10:01:00Z", "", []string{"ORD-1@tx1", "ORD-2@tx2"}},
+		{"2024-03-01T10:02:30Z", "", []string{"ORD-1@tx3", "ORD-2@tx2"}},
+		{"2024-03-01T10:03:00Z", "", []string{"ORD-1@tx3"}},
+		{"2024-03-01T10:02:30Z", `{"orderDetail":"10 pallets"}`, []string{"ORD-2@tx2"}},
+		{"2024-03-01T10:02:30Z", `{"version":2,"status":"Created","subtotal":null}`, []string{"ORD-1@tx3"}},
+	}
+	for _, tt := range tests {
+		got, err := ordersAsOf(tt.timestamp, tt.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("orders as of %s with %q = %v, want %v", tt.timestamp, tt.filter, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("orders as of %s with %q = %v, want %v", tt.timestamp, tt.filter, got, tt.want)
+				break
+			}
+		}
+	}
+
+	for _, filter := range []string{`["status"]`, `{"status":`, `{"lineItems":[]}`} {
+		if _, err := ordersAsOf("2024-03-01T10:00:00Z", filter); errorCode(err) != CodeInvalidArgument {
+			t.Errorf("filter %s: got %v", filter, err)
+		}
+	}
+}
+
+func TestGetOrdersAsOfPagination(t *testing.T) {
+	env := asOfEnv(t)
+	env.createOrder("ORD-3")
+
+	// ORD-2 was deleted before the instant, so its page is empty but not the last one
+	var pages []string
+	bookmark := ""
+	for len(pages) == 0 || bookmark != "" {
+		if len(pages) == 4 {
+			t.Fatal("paging did not end after 4 pages")
+		}
+		var page *PaginatedOrderSnapshots
+		env.mustSubmit(RoleAuditor, "GetOrdersAsOf", func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			page, err = env.contract.GetOrdersAsOf(ctx, "2024-03-02T00:00:00Z", "", 1, bookmark)
+			return err
+		})
+		var orderNos []string
+		for _, snapshot := range page.Records {
+			orderNos = append(orderNos, snapshot.OrderNo)
+		}
+		if int(page.FetchedRecordsCount) != len(page.Records) {
+			t.Errorf("fetched %d records, got %d", page.FetchedRecordsCount, len(page.Records))
+		}
+		pages = append(pages, strings.Join(orderNos, ","))
+		bookmark = page.Bookmark
+	}
+	if got := strings.Join(pages, "|"); got != "ORD-1||ORD-3" {
+		t.Errorf("paged through %q, want %q", got, "ORD-1||ORD-3")
+	}
+
+	for _, pageSize := range []int32{0, maxAsOfPageSize + 1} {
+		err := env.submit(RoleAuditor, "GetOrdersAsOf", func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.contract.GetOrdersAsOf(ctx, "2024-03-02T00:00:00Z", "", pageSize, "")
+			return err
+		})
+		if got := fieldRules(validationErrors(t, err)); len(got) != 1 || got["pageSize"] != "range" {
+			t.Errorf("page size %d: rejected fields = %v, want a range error on pageSize", pageSize, got)
+		}
+	}
+}
+
+func TestGetOrdersAsOfLegacyStatus(t *testing.T) {
+	env := newTestEnv(t)
+	env.stub.PutCommittedState("logis_ordr_1", []byte(`{"orderNo":"logis_ordr_1","date":"2024-03-01","orderDetail":"Sample order details 1",`+
+		`"invoice":"INV-001","packingStatus":"Packing","paymentMethod":"Credit Card","orderTrack":"In Progress"}`))
+	env.mustSubmit(RoleAdmin, "MigrateToCompositeKeys", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.contract.MigrateToCompositeKeys(ctx)
+		return err
+	})
+
+	for filter, want := range map[string]int{`{"status":"Packing"}`: 1, `{"status":"Created"}`: 0} {
+		var page *PaginatedOrderSnapshots
+		env.mustSubmit(RoleAuditor, "GetOrdersAsOf", func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			page, err = env.contract.GetOrdersAsOf(ctx, "2024-03-02T00:00:00Z", filter, maxAsOfPageSize, "")
+			return err
+		})
+		if len(page.Records) != want {
+			t.Errorf("filter %s matched %d legacy orders, want %d", filter, len(page.Records), want)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package ordermanagement
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Object types of the audit entries and of the pointer to the latest entry of each asset
+const (
+	auditObjectType     = "audit"
+	auditHeadObjectType = "audithead"
+)
+
+// AuditEntry is a link of the hash chained audit log kept for every asset. Hash covers
+// all other fields, including the hash of the previous entry, so rewriting any entry
+// breaks every following link
+type AuditEntry struct {
+	AssetType    string `json:"assetType"`
+	AssetID      string `json:"assetId"`
+	Sequence     int    `json:"sequence"`
+	Action       string `json:"action"`
+	Actor        string `json:"actor"`
+	MSPID        string `json:"mspId"`
+	TxID         string `json:"txId"`
+	Timestamp    string `json:"timestamp"`
+	StateHash    string `json:"stateHash"`
+	PreviousHash string `json:"previousHash"`
+	Hash         string `json:"hash"`
+}
+
+// auditHead points to the latest audit entry of an asset
+type auditHead struct {
+	Sequence int    `json:"sequence"`
+	Hash     string `json:"hash"`
+}
+
+// AuditVerification structure used for handling result of an audit chain verification
+type AuditVerification struct {
+	AssetKey string `json:"assetKey"`
+	Entries  int    `json:"entries"`
+	Valid    bool   `json:"valid"`
+	BrokenAt int    `json:"brokenAt,omitempty" metadata:",optional"`
+	Reason   string `json:"reason,omitempty" metadata:",optional"`
+}
+
+// computeHash returns the hash of the entry computed over all fields but the hash itself
+func (e AuditEntry) computeHash() (string, error) {
+	e.Hash = ""
+	entryJSON, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(entryJSON)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// recordMutation publishes the event of an asset mutation and appends it to the audit log of the asset
+func recordMutation(ctx contractapi.TransactionContextInterface, action string, assetType string, id string, previous []byte, current []byte) error {
+	err := emitEvent(ctx, action, assetType, id, previous, current)
+	if err != nil {
+		return err
+	}
+	return appendAudit(ctx, action, assetType, id, current)
+}
+
+// appendAudit appends an entry for the new state of an asset to its audit log
+func appendAudit(ctx contractapi.TransactionContextInterface, action string, assetType string, id string, state []byte) error {
+	timestamp, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	headKey, head, err := readAuditHead(ctx, assetType, id)
+	if err != nil {
+		return err
+	}
+
+	actor, _ := ctx.GetClientIdentity().GetID()
+	mspID, _ := ctx.GetClientIdentity().GetMSPID()
+
+	entry := AuditEntry{
+		AssetType:    assetType,
+		AssetID:      id,
+		Sequence:     head.Sequence + 1,
+		Action:       action,
+		Actor:        actor,
+		MSPID:        mspID,
+		TxID:         ctx.GetStub().GetTxID(),
+		Timestamp:    timestamp,
+		StateHash:    stateDigest(state),
+		PreviousHash: head.Hash,
+	}
+	entry.Hash, err = entry.computeHash()
+	if err != nil {
+		return err
+	}
+
+	entryJSON, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	entryKey, err := auditEntryKey(ctx, assetType, id, entry.Sequence)
+	if err != nil {
+		return err
+	}
+
+	err = ctx.GetStub().PutState(entryKey, entryJSON)
+	if err != nil {
+		return internalError("failed to put audit entry %d of %s %s to world state: %v", entry.Sequence, assetType, id, err)
+	}
+
+	headJSON, err := json.Marshal(auditHead{Sequence: entry.Sequence, Hash: entry.Hash})
+	if err != nil {
+		return err
+	}
+
+	err = ctx.GetStub().PutState(headKey, headJSON)
+	if err != nil {
+		return internalError("failed to put audit head of %s %s to world state: %v", assetType, id, err)
+	}
+	return nil
+}
+
+// readAuditHead returns the key of the audit head of an asset and its content, which
+// is empty when nothing has been audited for the asset yet
+func readAuditHead(ctx contractapi.TransactionContextInterface, assetType string, id string) (string, auditHead, error) {
+	head := auditHead{}
+
+	headKey, err := ctx.GetStub().CreateCompositeKey(auditHeadObjectType, []string{assetType, id})
+	if err != nil {
+		return "", head, invalidArgument("failed to create audit head key for %s %s: %v", assetType, id, err)
+	}
+
+	headJSON, err := ctx.GetStub().GetState(headKey)
+	if err != nil {
+		return "", head, internalError("failed to read audit head of %s %s: %v", assetType, id, err)
+	}
+	if headJSON != nil {
+		err = json.Unmarshal(headJSON, &head)
+		if err != nil {
+			return "", head, internalError("failed to decode audit head of %s %s: %v", assetType, id, err)
+		}
+	}
+
+	return headKey, head, nil
+}
+
+// auditEntryKey returns the key of an audit entry. The sequence number is zero padded
+// so that range queries return the entries in order
+func auditEntryKey(ctx contractapi.TransactionContextInterface, assetType string, id string, sequence int) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(auditObjectType, []string{assetType, id, fmt.Sprintf("%010d", sequence)})
+}
+
+// parseAssetKey splits an asset key of the form <assetType>:<id>
+func parseAssetKey(assetKey string) (string, string, error) {
+	parts := strings.SplitN(assetKey, ":", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", invalidArgument("the asset key %s is not of the form <assetType>:<id>", assetKey)
+	}
+	return parts[0], parts[1], nil
+}
+
+// GetAuditTrail returns the audit entries of an asset identified as <assetType>:<id>, oldest first
+func (s *SmartContract) GetAuditTrail(ctx contractapi.TransactionContextInterface, asset string) ([]AuditEntry, error) {
+	logger := newLogger(ctx)
+
+	// Log the start of the function
+	logger.Debug("Retrieving audit trail", "asset", asset)
+
+	// Validate the arguments
+	err := validate(ctx,
+		arg("asset", asset, required),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	assetType, id, err := parseAssetKey(asset)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := readAuditEntries(ctx, assetType, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Log the success of the operation
+	logger.Info("Audit trail retrieved", "asset", asset, "entries", len(entries))
+
+	return entries, nil
+}
+
+// VerifyAuditChain recomputes the audit chain of an asset identified as <assetType>:<id>
+// and reports the first broken link, if any
+func (s *SmartContract) VerifyAuditChain(ctx contractapi.TransactionContextInterface, asset string) (*AuditVerification, error) {
+	logger := newLogger(ctx)
+
+	// Log the start of the function
+	logger.Debug("Verifying audit chain", "asset", asset)
+
+	// Validate the arguments
+	err := validate(ctx,
+		arg("asset", asset, required),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	assetType, id, err := parseAssetKey(asset)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := readAuditEntries(ctx, assetType, id)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &AuditVerification{AssetKey: asset, Entries: len(entries), Valid: true}
+	broken := func(sequence int, reason string) (*AuditVerification, error) {
+		result.Valid = false
+		result.BrokenAt = sequence
+		result.Reason = reason
+		logger.Warn("Audit chain broken", "asset", asset, "sequence", sequence, "reason", reason)
+		return result, nil
+	}
+
+	previousHash := ""
+	for i, entry := range entries {
+		if entry.Sequence != i+1 {
+			return broken(i+1, fmt.Sprintf("expected entry %d, found entry %d", i+1, entry.Sequence))
+		}
+		if entry.AssetType != assetType || entry.AssetID != id {
+			return broken(entry.Sequence, "entry belongs to another asset")
+		}
+		if entry.PreviousHash != previousHash {
+			return broken(entry.Sequence, "previous hash does not match the preceding entry")
+		}
+		hash, err := entry.computeHash()
+		if err != nil {
+			return nil, err
+		}
+		if entry.Hash != hash {
+			return broken(entry.Sequence, "entry hash does not match its content")
+		}
+		previousHash = entry.Hash
+	}
+
+	_, head, err := readAuditHead(ctx, assetType, id)
+	if err != nil {
+		return nil, err
+	}
+	if head.Sequence != len(entries) || head.Hash != previousHash {
+		return broken(len(entries)+1, "audit head does not match the latest entry")
+	}
+
+	if len(entries) > 0 {
+		last := entries[len(entries)-1]
+
+		key, err := assetKey(ctx, assetType, id)
+		if err != nil {
+			return nil, err
+		}
+		state, err := ctx.GetStub().GetState(key)
+		if err != nil {
+			return nil, internalError("failed to read %s from world state: %v", asset, err)
+		}
+		if stateDigest(state) != last.StateHash {
+			return broken(last.Sequence, "current state does not match the latest entry")
+		}
+	}
+
+	// Log the success of the operation
+	logger.Info("Audit chain verified", "asset", asset, "entries", len(entries))
+
+	return result, nil
+}
+
+// readAuditEntries returns the audit entries of an asset ordered by sequence number
+func readAuditEntries(ctx contractapi.TransactionContextInterface, assetType string, id string) ([]AuditEntry, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(auditObjectType, []string{assetType, id})
+	if err != nil {
+		return nil, internalError("failed to read audit trail of %s %s: %v", assetType, id, err)
+	}
+	defer resultsIterator.Close()
+
+	entries := []AuditEntry{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, internalError("failed to read query results: %v", err)
+		}
+
+		var entry AuditEntry
+		err = json.Unmarshal(queryResponse.Value, &entry)
+		if err != nil {
+			return nil, internalError("failed to decode audit entry %s: %v", queryResponse.Key, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,129 @@
+package ordermanagement
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// auditTrail returns the audit entries of an asset as an auditor
+func (e *testEnv) auditTrail(asset string) []AuditEntry {
+	e.t.Helper()
+	var entries []AuditEntry
+	e.mustSubmit(RoleAuditor, "GetAuditTrail", func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		entries, err = e.contract.GetAuditTrail(ctx, asset)
+		return err
+	})
+	return entries
+}
+
+// verifyAudit verifies the audit chain of an asset as an auditor
+func (e *testEnv) verifyAudit(asset string) *AuditVerification {
+	e.t.Helper()
+	var result *AuditVerification
+	e.mustSubmit(RoleAuditor, "VerifyAuditChain", func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		result, err = e.contract.VerifyAuditChain(ctx, asset)
+		return err
+	})
+	return result
+}
+
+// auditedOrder creates an order and moves it through two more audited mutations
+func auditedOrder(t *testing.T) *testEnv {
+	env := newTestEnv(t)
+	env.createOrder("ORD-1")
+	if err := confirm.run(env, "ORD-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := startPacking.run(env, "ORD-1"); err != nil {
+		t.Fatal(err)
+	}
+	return env
+}
+
+func TestAuditTrail(t *testing.T) {
+	env := auditedOrder(t)
+
+	entries := env.auditTrail("order:ORD-1")
+	if len(entries) != 3 {
+		t.Fatalf("got %d audit entries, want 3", len(entries))
+	}
+
+	actions := []string{EventOrderCreated, EventOrderStatusChanged, EventOrderStatusChanged}
+	previousHash := ""
+	for i, entry := range entries {
+		if entry.Sequence != i+1 || entry.Action != actions[i] || entry.AssetType != orderObjectType || entry.AssetID != "ORD-1" {
+			t.Errorf("unexpected audit entry %d: %+v", i, entry)
+		}
+		if entry.PreviousHash != previousHash {
+			t.Errorf("entry %d links to %s, want %s", entry.Sequence, entry.PreviousHash, previousHash)
+		}
+		previousHash = entry.Hash
+	}
+	if entries[0].Actor != testIdentity(RoleSeller).ID || entries[0].Timestamp != "2024-03-01T10:00:00Z" {
+		t.Errorf("unexpected actor or timestamp: %+v", entries[0])
+	}
+
+	if entries := env.auditTrail("order:ORD-2"); len(entries) != 0 {
+		t.Errorf("got %d audit entries for a missing order, want none", len(entries))
+	}
+}
+
+func TestVerifyAuditChain(t *testing.T) {
+	env := auditedOrder(t)
+
+	result := env.verifyAudit("order:ORD-1")
+	if !result.Valid || result.Entries != 3 || result.BrokenAt != 0 {
+		t.Errorf("unexpected verification of an untouched chain: %+v", result)
+	}
+}
+
+func TestVerifyAuditChainTamperedEntry(t *testing.T) {
+	env := auditedOrder(t)
+
+	key, _ := env.stub.CreateCompositeKey(auditObjectType, []string{orderObjectType, "ORD-1", "0000000002"})
+	var entry AuditEntry
+	if err := json.Unmarshal(env.stub.CommittedState(key), &entry); err != nil {
+		t.Fatal(err)
+	}
+	entry.Actor = "x509::CN=mallory::CN=ca.org1.example.com"
+	entryJSON, _ := json.Marshal(entry)
+	env.stub.PutCommittedState(key, entryJSON)
+
+	result := env.verifyAudit("order:ORD-1")
+	if result.Valid || result.BrokenAt != 2 {
+		t.Errorf("unexpected verification of a tampered entry: %+v", result)
+	}
+}
+
+func TestVerifyAuditChainTamperedState(t *testing.T) {
+	env := auditedOrder(t)
+
+	key, _ := env.stub.CreateCompositeKey(orderObjectType, []string{"ORD-1"})
+	var order Order
+	if err := json.Unmarshal(env.stub.CommittedState(key), &order); err != nil {
+		t.Fatal(err)
+	}
+	order.Invoice = "INV-FORGED"
+	orderJSON, _ := json.Marshal(order)
+	env.stub.PutCommittedState(key, orderJSON)
+
+	result := env.verifyAudit("order:ORD-1")
+	if result.Valid || result.BrokenAt != 3 {
+		t.Errorf("unexpected verification of a tampered order: %+v", result)
+	}
+}
+
+func TestVerifyAuditChainBadAssetKey(t *testing.T) {
+	env := newTestEnv(t)
+	err := env.submit(RoleAuditor, "VerifyAuditChain", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.contract.VerifyAuditChain(ctx, "ORD-1")
+		return err
+	})
+	if err == nil {
+		t.Error("expected an error for an asset key without a type")
+	}
+}
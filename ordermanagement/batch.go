@@ -0,0 +1,467 @@
+package ordermanagement
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// settingsObjectType is the composite key namespace of the contract settings
+const settingsObjectType = "settings"
+
+// batchSettingsID is the key of the batch settings in their namespace
+const batchSettingsID = "batch"
+
+// Bounds of the number of orders a batch may hold. The default applies until an admin
+// sets another maximum with SetMaxBatchSize, which is capped to keep endorsement time low
+const (
+	defaultMaxBatchSize = 100
+	maxBatchSizeLimit   = 1000
+)
+
+// Statuses of the items of a batch report
+const (
+	BatchItemCreated  = "created"
+	BatchItemUpdated  = "updated"
+	BatchItemValid    = "valid"
+	BatchItemRejected = "rejected"
+)
+
+// BatchSettings holds the limits of CreateOrdersBatch and UpdateOrdersBatch
+type BatchSettings struct {
+	MaxBatchSize int    `json:"maxBatchSize"`
+	UpdatedAt    string `json:"updatedAt,omitempty" metadata:",optional"`
+}
+
+// BatchOrder is an order of CreateOrdersBatch or UpdateOrdersBatch, with the parameters
+// of CreateOrder and UpdateOrder. The invoice and line items are private and cannot be
+// passed in a batch, they must be left empty. Version is required by UpdateOrdersBatch and
+// must be the current version of the order like in PatchOrder, 0 for the orders recorded
+// before versions were kept. CreateOrdersBatch ignores it
+type BatchOrder struct {
+	OrderNo       string     `json:"orderNo"`
+	Date          string     `json:"date"`
+	OrderDetail   string     `json:"orderDetail"`
+	Invoice       string     `json:"invoice"`
+	PaymentMethod string     `json:"paymentMethod"`
+	LineItems     []LineItem `json:"lineItems,omitempty"`
+	Version       *int       `json:"version,omitempty" metadata:",optional"`
+}
+
+// BatchReport is the outcome of a batch. The batch is applied only when every item is
+// valid, otherwise nothing is written and the report is returned in a BatchError
+type BatchReport struct {
+	Applied bool              `json:"applied"`
+	Items   []BatchItemResult `json:"items"`
+}
+
+// BatchError is returned when an item of a batch is rejected. It is reported as a
+// ContractError listing every item, the valid ones included, with the code the rejected
+// items share or INVALID_ARGUMENT when they differ
+type BatchError struct {
+	Function string
+	Report   *BatchReport
+}
+
+func (e *BatchError) Error() string {
+	return e.contractError().Error()
+}
+
+func (e *BatchError) contractError() *ContractError {
+	var code ErrorCode
+	rejected := 0
+	for _, item := range e.Report.Items {
+		if item.Status != BatchItemRejected {
+			continue
+		}
+		if code == "" {
+			code = item.Code
+		} else if code != item.Code {
+			code = CodeInvalidArgument
+		}
+		rejected++
+	}
+
+	err := newError(code, map[string]string{"function": e.Function, "rejected": strconv.Itoa(rejected)},
+		"%d of the %d orders of the batch were rejected, nothing was written", rejected, len(e.Report.Items))
+	err.Items = e.Report.Items
+	return err
+}
+
+// BatchItemResult is the outcome of an item of a batch, given by its position. Code,
+// Message and Errors describe why a rejected item was rejected, as in ContractError
+type BatchItemResult struct {
+	Index   int          `json:"index"`
+	OrderNo string       `json:"orderNo"`
+	Status  string       `json:"status"`
+	Code    ErrorCode    `json:"code,omitempty" metadata:",optional"`
+	Message string       `json:"message,omitempty" metadata:",optional"`
+	Errors  []FieldError `json:"errors,omitempty" metadata:",optional"`
+}
+
+// reject marks the item rejected with the given error
+func (r *BatchItemResult) reject(err error) {
+	contractErr := asContractError(err, CodeInternal)
+	r.Status = BatchItemRejected
+	r.Code = contractErr.Code
+	r.Message = contractErr.Message
+	r.Errors = contractErr.Errors
+}
+
+// preparedOrder is a validated order of a batch ready to be written
+type preparedOrder struct {
+	key   string
+	order *Order
+}
+
+// CreateOrdersBatch creates the orders of a JSON array of BatchOrder in a single
+// transaction. Every order is validated like in CreateOrder and the batch is applied only
+// when all of them are valid, otherwise a BatchError reports every order. The invoice and
+// line items are private and cannot be passed in a batch, neither as parameters nor in
+// the transient map, use CreateOrder or UpdateOrder for orders that have them. A single
+// OrdersBatchCreated event lists the created orders
+func (s *SmartContract) CreateOrdersBatch(ctx contractapi.TransactionContextInterface, ordersJSON string) (*BatchReport, error) {
+	return s.applyOrdersBatch(ctx, ordersJSON, BatchItemCreated, EventOrderCreated, EventOrdersBatchCreated, prepareNewOrder)
+}
+
+// UpdateOrdersBatch updates the orders of a JSON array of BatchOrder in a single
+// transaction, like CreateOrdersBatch. Every order is validated like in UpdateOrder and
+// must give the version it was read at, orders keeping their invoice and line items
+// private cannot be updated in a batch
+func (s *SmartContract) UpdateOrdersBatch(ctx contractapi.TransactionContextInterface, ordersJSON string) (*BatchReport, error) {
+	return s.applyOrdersBatch(ctx, ordersJSON, BatchItemUpdated, EventOrderUpdated, EventOrdersBatchUpdated, prepareUpdatedOrder)
+}
+
+// applyOrdersBatch validates every order of a batch with prepare and writes them all
+// when none is rejected. Each order gets its audit entry while the batch gets a single
+// event, since Fabric keeps one event per transaction
+func (s *SmartContract) applyOrdersBatch(ctx contractapi.TransactionContextInterface, ordersJSON string, applied string, action string, event string,
+	prepare func(ctx contractapi.TransactionContextInterface, timestamp string, methods []string, input BatchOrder) (*preparedOrder, error)) (*BatchReport, error) {
+	// Record the transaction timestamp
+	timestamp, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	logger := newLogger(ctx)
+
+	// Log the start of the function
+	logger.Debug("Applying order batch", "bytes", len(ordersJSON))
+
+	// Validate the arguments
+	err = validate(ctx,
+		arg("orders", ordersJSON, required),
+	)
+	if err != nil {
+		return nil, err
+	}
+	private, err := transientPrivate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if private != nil {
+		return nil, newError(CodeInvalidArgument, map[string]string{"transient": privateTransientKey},
+			"private details cannot be passed to a batch, use CreateOrder or UpdateOrder for orders that have them")
+	}
+
+	settings, err := batchSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	inputs, err := decodeBatch(ordersJSON, settings.MaxBatchSize)
+	if err != nil {
+		return nil, err
+	}
+	methods, err := paymentMethods(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate every order before writing any. The world state does not reflect the
+	// writes of the transaction, so an order may only appear once
+	report := &BatchReport{Items: make([]BatchItemResult, len(inputs))}
+	prepared := make([]*preparedOrder, len(inputs))
+	seen := map[string]int{}
+	rejected := 0
+	for i, input := range inputs {
+		item := &report.Items[i]
+		item.Index = i
+		item.OrderNo = input.OrderNo
+		item.Status = BatchItemValid
+
+		if first, ok := seen[input.OrderNo]; ok && input.OrderNo != "" {
+			item.reject(newError(CodeInvalidArgument, map[string]string{"orderNo": input.OrderNo, "index": strconv.Itoa(first)},
+				"the order %s already appears at index %d of the batch", input.OrderNo, first))
+			rejected++
+			continue
+		}
+		seen[input.OrderNo] = i
+
+		prepared[i], err = prepare(ctx, timestamp, methods, input)
+		if err != nil {
+			if errorCode(err) == CodeInternal {
+				return nil, err
+			}
+			item.reject(err)
+			rejected++
+		}
+	}
+	if rejected > 0 {
+		// Log the rejection of the batch
+		logger.Warn("Order batch rejected", "orders", len(inputs), "rejected", rejected)
+
+		function, _ := ctx.GetStub().GetFunctionAndParameters()
+		return nil, &BatchError{Function: functionName(function), Report: report}
+	}
+
+	orderNos := make([]string, len(prepared))
+	for i, p := range prepared {
+		orderJSON, err := json.Marshal(p.order)
+		if err != nil {
+			return nil, err
+		}
+
+		err = ctx.GetStub().PutState(p.key, orderJSON)
+		if err != nil {
+			return nil, internalError("failed to put order %s to world state: %v", p.order.OrderNo, err)
+		}
+
+		err = appendAudit(ctx, action, orderObjectType, p.order.OrderNo, orderJSON)
+		if err != nil {
+			return nil, err
+		}
+
+		orderNos[i] = p.order.OrderNo
+		report.Items[i].Status = applied
+	}
+	report.Applied = true
+
+	// Publish the batch event
+	err = emitBatchEvent(ctx, event, orderObjectType, orderNos)
+	if err != nil {
+		return nil, err
+	}
+
+	// Log the success of the operation
+	logger.Info("Order batch applied", "orders", len(orderNos), "status", applied)
+
+	return report, nil
+}
+
+// decodeBatch decodes a JSON array of orders one order at a time, so that a batch holding
+// more than maxBatchSize orders is rejected without decoding the rest of it
+func decodeBatch(ordersJSON string, maxBatchSize int) ([]BatchOrder, error) {
+	sizeError := func(size string) error {
+		return newError(CodeInvalidArgument, map[string]string{"maxBatchSize": strconv.Itoa(maxBatchSize)},
+			"a batch must hold between 1 and %d orders, got %s", maxBatchSize, size)
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(ordersJSON))
+	token, err := decoder.Token()
+	if err != nil || token != json.Delim('[') {
+		return nil, invalidArgument("the orders must be a JSON array of orders")
+	}
+	var inputs []BatchOrder
+	for decoder.More() {
+		if len(inputs) == maxBatchSize {
+			return nil, sizeError("more")
+		}
+		var input BatchOrder
+		err = decoder.Decode(&input)
+		if err != nil {
+			return nil, invalidArgument("the orders must be a JSON array of orders: %v", err)
+		}
+		inputs = append(inputs, input)
+	}
+	_, err = decoder.Token()
+	if err != nil {
+		return nil, invalidArgument("the orders must be a JSON array of orders: %v", err)
+	}
+	if _, err = decoder.Token(); err != io.EOF {
+		return nil, invalidArgument("the orders must be a single JSON array of orders")
+	}
+	if len(inputs) == 0 {
+		return nil, sizeError("none")
+	}
+	return inputs, nil
+}
+
+// prepareNewOrder validates an order of CreateOrdersBatch like CreateOrder and builds it
+func prepareNewOrder(ctx contractapi.TransactionContextInterface, timestamp string, methods []string, input BatchOrder) (*preparedOrder, error) {
+	err := validateOrder(ctx, methods, input.Date, input.OrderDetail, input.PaymentMethod,
+		newIDArg("orderNo", input.OrderNo),
+		privateArg("invoice", input.Invoice != ""),
+		privateArg("lineItems", len(input.LineItems) > 0),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := assetKey(ctx, orderObjectType, input.OrderNo)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, internalError("failed to read order %s from world state: %v", input.OrderNo, err)
+	}
+	if existing != nil {
+		return nil, alreadyExists(orderObjectType, input.OrderNo)
+	}
+
+	order := &Order{
+		DocType:       orderObjectType,
+		OrderNo:       input.OrderNo,
+		Date:          input.Date,
+		OrderDetail:   input.OrderDetail,
+		PaymentMethod: input.PaymentMethod,
+	}
+	order.setStatus(StatusCreated)
+	order.CreatedAt = timestamp
+	order.UpdatedAt = timestamp
+	order.Version++
+
+	return &preparedOrder{key: key, order: order}, nil
+}
+
+// prepareUpdatedOrder validates an order of UpdateOrdersBatch like UpdateOrder and applies
+// it to the current order
+func prepareUpdatedOrder(ctx contractapi.TransactionContextInterface, timestamp string, methods []string, input BatchOrder) (*preparedOrder, error) {
+	version := ""
+	if input.Version != nil {
+		version = strconv.Itoa(*input.Version)
+	}
+	err := validateOrder(ctx, methods, input.Date, input.OrderDetail, input.PaymentMethod,
+		idArg("orderNo", input.OrderNo),
+		privateArg("invoice", input.Invoice != ""),
+		privateArg("lineItems", len(input.LineItems) > 0),
+		arg("version", version, required, atLeast(0)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := assetKey(ctx, orderObjectType, input.OrderNo)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, internalError("failed to read order %s from world state: %v", input.OrderNo, err)
+	}
+	if previous == nil {
+		return nil, notFound(orderObjectType, input.OrderNo)
+	}
+
+	order := &Order{}
+	err = json.Unmarshal(previous, order)
+	if err != nil {
+		return nil, internalError("failed to decode order %s: %v", input.OrderNo, err)
+	}
+
+	if order.Version != *input.Version {
+		return nil, newError(CodeConflict, map[string]string{
+			"type":            orderObjectType,
+			"id":              input.OrderNo,
+			"expectedVersion": version,
+			"version":         strconv.Itoa(order.Version),
+		}, "the order %s is at version %d, not %s", input.OrderNo, order.Version, version)
+	}
+	if status := order.lifecycleStatus(); isFinal(status) {
+		return nil, finalStatus(orderObjectType, input.OrderNo, string(status))
+	}
+	if order.PrivateHash != "" {
+		return nil, invalidArgument("the order %s keeps its invoice and line items private, update it with UpdateOrder", input.OrderNo)
+	}
+
+	order.Date = input.Date
+	order.OrderDetail = input.OrderDetail
+	order.PaymentMethod = input.PaymentMethod
+	order.DocType = orderObjectType
+	order.UpdatedAt = timestamp
+	order.Version++
+
+	return &preparedOrder{key: key, order: order}, nil
+}
+
+// batchSettings returns the batch settings, or the defaults when none were set
+func batchSettings(ctx contractapi.TransactionContextInterface) (*BatchSettings, error) {
+	key, err := assetKey(ctx, settingsObjectType, batchSettingsID)
+	if err != nil {
+		return nil, err
+	}
+
+	settingsJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, internalError("failed to read batch settings from world state: %v", err)
+	}
+	settings := &BatchSettings{MaxBatchSize: defaultMaxBatchSize}
+	if settingsJSON != nil {
+		err = json.Unmarshal(settingsJSON, settings)
+		if err != nil {
+			return nil, internalError("failed to decode batch settings: %v", err)
+		}
+	}
+	return settings, nil
+}
+
+// SetMaxBatchSize sets the largest number of orders CreateOrdersBatch and
+// UpdateOrdersBatch accept, at most 1000. The setting is kept in the world state so
+// that every endorsing peer applies the same limit
+func (s *SmartContract) SetMaxBatchSize(ctx contractapi.TransactionContextInterface, maxBatchSize int) error {
+	// Record the transaction timestamp
+	timestamp, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	logger := newLogger(ctx)
+
+	// Log parameter details
+	logger.Debug("Setting maximum batch size", "maxBatchSize", maxBatchSize)
+
+	// Validate the arguments
+	err = validate(ctx,
+		intArg("maxBatchSize", maxBatchSize, atLeast(1), atMost(maxBatchSizeLimit)),
+	)
+	if err != nil {
+		return err
+	}
+
+	settingsJSON, err := json.Marshal(BatchSettings{MaxBatchSize: maxBatchSize, UpdatedAt: timestamp})
+	if err != nil {
+		return err
+	}
+
+	key, err := assetKey(ctx, settingsObjectType, batchSettingsID)
+	if err != nil {
+		return err
+	}
+
+	previousJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return internalError("failed to read batch settings from world state: %v", err)
+	}
+
+	err = ctx.GetStub().PutState(key, settingsJSON)
+	if err != nil {
+		return internalError("failed to put batch settings to world state: %v", err)
+	}
+
+	// Publish settings event
+	err = recordMutation(ctx, EventSettingsChanged, settingsObjectType, batchSettingsID, previousJSON, settingsJSON)
+	if err != nil {
+		return err
+	}
+
+	// Log the success of the operation
+	logger.Info("Maximum batch size set", "maxBatchSize", maxBatchSize)
+
+	return nil
+}
+
+// GetBatchSettings returns the limits of CreateOrdersBatch and UpdateOrdersBatch
+func (s *SmartContract) GetBatchSettings(ctx contractapi.TransactionContextInterface) (*BatchSettings, error) {
+	return batchSettings(ctx)
+}
@@ -0,0 +1,240 @@
+package ordermanagement
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// submitBatch runs a batch function as a seller
+func (e *testEnv) submitBatch(function string, ordersJSON string) (*BatchReport, error) {
+	var report *BatchReport
+	err := e.submit(RoleSeller, function, func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		if function == "CreateOrdersBatch" {
+			report, err = e.contract.CreateOrdersBatch(ctx, ordersJSON)
+		} else {
+			report, err = e.contract.UpdateOrdersBatch(ctx, ordersJSON)
+		}
+		return err
+	})
+	return report, err
+}
+
+// rejectedBatch returns the code of the BatchError of a rejected batch and its report
+func rejectedBatch(t *testing.T, err error) (ErrorCode, *BatchReport) {
+	t.Helper()
+	decoded := decodeError(t, err)
+	return decoded.Code, &BatchReport{Items: decoded.Items}
+}
+
+// itemStatuses returns the status of every item of a report
+func itemStatuses(report *BatchReport) []string {
+	statuses := make([]string, len(report.Items))
+	for i, item := range report.Items {
+		statuses[i] = item.Status
+		if item.Code != "" {
+			statuses[i] += ":" + string(item.Code)
+		}
+	}
+	return statuses
+}
+
+func TestCreateOrdersBatch(t *testing.T) {
+	env := newTestEnv(t)
+	env.createOrder("ORD-1")
+
+	// A single invalid order rejects the whole batch
+	_, err := env.submitBatch("CreateOrdersBatch", `[
+		{"orderNo":"ORD-2","date":"2024-03-01","orderDetail":"pallets","paymentMethod":"ACH"},
+		{"orderNo":"ORD-1","date":"2024-03-01","paymentMethod":"ACH"},
+		{"orderNo":"ORD-3","date":"01/03/2024","paymentMethod":"Barter"},
+		{"orderNo":"ORD-2","date":"2024-03-01","paymentMethod":"ACH"},
+		{"orderNo":"ORD-4","date":"2024-03-01","paymentMethod":"ACH","invoice":"INV-4","lineItems":[{"sku":"PAL-1","quantity":2,"unit":"pcs","unitPrice":{"amount":12050,"currency":"EUR"}}]}
+	]`)
+	code, report := rejectedBatch(t, err)
+	want := []string{"valid", "rejected:ALREADY_EXISTS", "rejected:INVALID_ARGUMENT", "rejected:INVALID_ARGUMENT", "rejected:INVALID_ARGUMENT"}
+	if code != CodeInvalidArgument || strings.Join(itemStatuses(report), ",") != strings.Join(want, ",") {
+		t.Errorf("got %s with items %+v, want INVALID_ARGUMENT with items %v", code, report.Items, want)
+	}
+	if got := fieldRules(&ContractError{Errors: report.Items[2].Errors}); got["date"] != "date" || got["paymentMethod"] != "enum" {
+		t.Errorf("rejected fields of item 2 = %v", got)
+	}
+	if got := fieldRules(&ContractError{Errors: report.Items[4].Errors}); got["invoice"] != "private" || got["lineItems"] != "private" {
+		t.Errorf("rejected fields of item 4 = %v", got)
+	}
+	if env.stub.CommittedState(orderKey(t, env, "ORD-2")) != nil {
+		t.Error("a rejected batch wrote ORD-2")
+	}
+
+	// Private details cannot be passed in the transient map either
+	err = env.submitPrivate(RoleSeller, "CreateOrdersBatch", orderPrivate, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.contract.CreateOrdersBatch(ctx, `[{"orderNo":"ORD-2","date":"2024-03-01","paymentMethod":"ACH"}]`)
+		return err
+	})
+	if decoded := decodeError(t, err); decoded.Code != CodeInvalidArgument || decoded.Details["transient"] != privateTransientKey {
+		t.Errorf("batch with private details: got %+v", decoded)
+	}
+
+	// A valid batch writes every order, audits each one and emits a single event
+	report, err = env.submitBatch("CreateOrdersBatch", `[
+		{"orderNo":"ORD-2","date":"2024-03-01","orderDetail":"pallets","paymentMethod":"ACH"},
+		{"orderNo":"ORD-3","date":"2024-03-02","paymentMethod":"Wire"}
+	]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Applied || strings.Join(itemStatuses(report), ",") != "created,created" {
+		t.Errorf("report = %+v, want both orders created", report)
+	}
+	event := env.lastEvent()
+	if events := env.stub.Events(); len(events) != 2 {
+		t.Errorf("got %d events, want one for ORD-1 and one for the batch", len(events))
+	}
+	if order := env.readOrder("ORD-3"); order.Status != StatusCreated || order.Version != 1 || order.PaymentMethod != "Wire" {
+		t.Errorf("unexpected created order: %+v", order)
+	}
+
+	var payload BatchEvent
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if event.Name != EventOrdersBatchCreated || strings.Join(payload.Keys, ",") != "ORD-2,ORD-3" || payload.AssetType != orderObjectType {
+		t.Errorf("unexpected batch event %s: %+v", event.Name, payload)
+	}
+	for _, orderNo := range []string{"ORD-2", "ORD-3"} {
+		err := env.submit(RoleAuditor, "VerifyAuditChain", func(ctx contractapi.TransactionContextInterface) error {
+			verification, err := env.contract.VerifyAuditChain(ctx, "order:"+orderNo)
+			if err == nil && (!verification.Valid || verification.Entries != 1) {
+				err = fmt.Errorf("unexpected audit chain %+v", verification)
+			}
+			return err
+		})
+		if err != nil {
+			t.Errorf("audit chain of %s: %v", orderNo, err)
+		}
+	}
+}
+
+func TestUpdateOrdersBatch(t *testing.T) {
+	env := newTestEnv(t)
+	env.createOrder("ORD-1")
+	env.createOrder("ORD-2")
+	env.createOrder("ORD-3")
+	if err := cancel.run(env, "ORD-3"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := env.submitBatch("UpdateOrdersBatch", `[
+		{"orderNo":"ORD-1","date":"2024-03-01","orderDetail":"12 pallets","paymentMethod":"ACH","version":2},
+		{"orderNo":"ORD-3","date":"2024-03-01","paymentMethod":"ACH","version":2},
+		{"orderNo":"ORD-4","date":"2024-03-01","paymentMethod":"ACH","version":1}
+	]`)
+	code, report := rejectedBatch(t, err)
+	want := "rejected:CONFLICT,rejected:INVALID_STATE_TRANSITION,rejected:NOT_FOUND"
+	if code != CodeInvalidArgument || strings.Join(itemStatuses(report), ",") != want {
+		t.Errorf("got %s with items %+v, want INVALID_ARGUMENT with items %s", code, report.Items, want)
+	}
+
+	// The code of the error is the one the rejected items share
+	_, err = env.submitBatch("UpdateOrdersBatch", `[
+		{"orderNo":"ORD-1","date":"2024-03-01","paymentMethod":"ACH","version":2},
+		{"orderNo":"ORD-2","date":"2024-03-01","paymentMethod":"ACH","version":1}
+	]`)
+	if code, report := rejectedBatch(t, err); code != CodeConflict || strings.Join(itemStatuses(report), ",") != "rejected:CONFLICT,valid" {
+		t.Errorf("got %s with items %+v, want a CONFLICT", code, report.Items)
+	}
+
+	// The version is required, so that no concurrent update is overwritten
+	_, err = env.submitBatch("UpdateOrdersBatch", `[{"orderNo":"ORD-1","date":"2024-03-01","paymentMethod":"ACH"}]`)
+	if _, report := rejectedBatch(t, err); len(report.Items) != 1 || fieldRules(&ContractError{Errors: report.Items[0].Errors})["version"] != "required" {
+		t.Errorf("batch without a version: got %+v", report.Items)
+	}
+
+	report, err = env.submitBatch("UpdateOrdersBatch", `[
+		{"orderNo":"ORD-1","date":"2024-03-01","orderDetail":"12 pallets","paymentMethod":"ACH","version":1},
+		{"orderNo":"ORD-2","date":"2024-03-05","orderDetail":"3 pallets","paymentMethod":"Wire","version":1}
+	]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Applied || strings.Join(itemStatuses(report), ",") != "updated,updated" {
+		t.Errorf("report = %+v, want both orders updated", report)
+	}
+	if order := env.readOrder("ORD-2"); order.Date != "2024-03-05" || order.PaymentMethod != "Wire" || order.Version != 2 {
+		t.Errorf("unexpected updated order: %+v", order)
+	}
+}
+
+func TestUpdateLegacyOrdersBatch(t *testing.T) {
+	env := newTestEnv(t)
+	env.stub.PutCommittedState("logis_ordr_1", []byte(`{"orderNo":"logis_ordr_1","date":"2024-03-01","orderDetail":"Sample order details 1",`+
+		`"packingStatus":"Packing","paymentMethod":"Credit Card","orderTrack":"In Progress"}`))
+	env.mustSubmit(RoleAdmin, "MigrateToCompositeKeys", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.contract.MigrateToCompositeKeys(ctx)
+		return err
+	})
+
+	// Orders recorded before versions were kept are at version 0, like in UpdateOrder
+	_, err := env.submitBatch("UpdateOrdersBatch", `[{"orderNo":"logis_ordr_1","date":"2024-03-01","paymentMethod":"ACH","version":-1}]`)
+	if _, report := rejectedBatch(t, err); fieldRules(&ContractError{Errors: report.Items[0].Errors})["version"] != "range" {
+		t.Errorf("negative version: got %+v", report.Items)
+	}
+	report, err := env.submitBatch("UpdateOrdersBatch", `[{"orderNo":"logis_ordr_1","date":"2024-03-05","paymentMethod":"ACH","version":0}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Applied {
+		t.Errorf("report = %+v, want the legacy order updated", report)
+	}
+	if order := env.readOrder("logis_ordr_1"); order.Date != "2024-03-05" || order.PaymentMethod != "ACH" || order.Version != 1 {
+		t.Errorf("unexpected updated order: %+v", order)
+	}
+}
+
+func TestBatchSize(t *testing.T) {
+	env := newTestEnv(t)
+
+	orders := make([]BatchOrder, 3)
+	for i := range orders {
+		orders[i] = BatchOrder{OrderNo: fmt.Sprintf("ORD-%d", i+1), Date: "2024-03-01", PaymentMethod: "ACH"}
+	}
+	ordersJSON, _ := json.Marshal(orders)
+
+	env.mustSubmit(RoleAdmin, "SetMaxBatchSize", func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.SetMaxBatchSize(ctx, 2)
+	})
+	_, err := env.submitBatch("CreateOrdersBatch", string(ordersJSON))
+	if decoded := decodeError(t, err); decoded.Code != CodeInvalidArgument || decoded.Details["maxBatchSize"] != "2" {
+		t.Errorf("oversized batch: got %+v", decoded)
+	}
+	// The size is checked before the orders past the maximum are decoded
+	_, err = env.submitBatch("CreateOrdersBatch", `[{"orderNo":"ORD-1"},{"orderNo":"ORD-2"},{"orderNo":3}]`)
+	if decoded := decodeError(t, err); decoded.Details["maxBatchSize"] != "2" {
+		t.Errorf("oversized batch with an undecodable order: got %+v", decoded)
+	}
+	for _, ordersJSON := range []string{"[]", `{"orderNo":"ORD-1"}`, `[{"orderNo":"ORD-1"}] []`, `[{"orderNo":"ORD-1"}`} {
+		if _, err := env.submitBatch("CreateOrdersBatch", ordersJSON); errorCode(err) != CodeInvalidArgument {
+			t.Errorf("batch %s: got %v", ordersJSON, err)
+		}
+	}
+
+	for _, size := range []int{0, maxBatchSizeLimit + 1} {
+		err := env.submit(RoleAdmin, "SetMaxBatchSize", func(ctx contractapi.TransactionContextInterface) error {
+			return env.contract.SetMaxBatchSize(ctx, size)
+		})
+		if errorCode(err) != CodeInvalidArgument {
+			t.Errorf("maximum batch size %d: got %v", size, err)
+		}
+	}
+	env.mustSubmit(RoleAuditor, "GetBatchSettings", func(ctx contractapi.TransactionContextInterface) error {
+		settings, err := env.contract.GetBatchSettings(ctx)
+		if err == nil && settings.MaxBatchSize != 2 {
+			err = fmt.Errorf("maximum batch size = %d, want 2", settings.MaxBatchSize)
+		}
+		return err
+	})
+}
@@ -0,0 +1,888 @@
+package ordermanagement
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// SmartContract provides functions for managing supply chain, shipments, and payments
+type SmartContract struct {
+	contractapi.Contract
+}
+
+// NewSmartContract returns the contract with the access policy check installed
+func NewSmartContract() *SmartContract {
+	return &SmartContract{
+		Contract: contractapi.Contract{BeforeTransaction: checkAccess},
+	}
+}
+
+// Order represents the order information
+type Order struct {
+	// DocType lets rich queries tell orders apart from the other documents of the state database
+	DocType     string `json:"docType"`
+	OrderNo     string `json:"orderNo"`
+	Date        string `json:"date"`
+	OrderDetail string `json:"orderDetail"`
+	// LineItems are the ordered articles, Subtotal, Tax and Total are their sums and are
+	// only set on orders with line items
+	LineItems     []LineItem `json:"lineItems,omitempty" metadata:",optional"`
+	Subtotal      *Money     `json:"subtotal,omitempty" metadata:",optional"`
+	Tax           *Money     `json:"tax,omitempty" metadata:",optional"`
+	Total         *Money     `json:"total,omitempty" metadata:",optional"`
+	Invoice       string     `json:"invoice"`
+	PackingStatus string     `json:"packingStatus"`
+	PaymentMethod string     `json:"paymentMethod"`
+	OrderTrack    string     `json:"orderTrack"`
+	// Status is the lifecycle status, PackingStatus and OrderTrack are derived from it
+	Status      OrderStatus        `json:"status"`
+	Transitions []StatusTransition `json:"transitions,omitempty" metadata:",optional"`
+	CreatedAt   string             `json:"createdAt"`
+	UpdatedAt   string             `json:"updatedAt"`
+	// PrivateHash is the salted hash of the invoice and line items when they are kept in
+	// the orderPrivateDetails collection rather than in the order, see OrderPrivateDetails
+	PrivateHash string `json:"privateHash,omitempty" metadata:",optional"`
+	// Version is incremented by every change to the order, see PatchOrder
+	Version int `json:"version"`
+}
+
+// TransactionType represents the type of transaction. The accepted types and their rules
+// are kept in the payment rail registry, see PaymentRail
+type TransactionType string
+
+const (
+	ACHTransaction            TransactionType = "ACH"
+	CreditCardTransaction     TransactionType = "CreditCard"
+	WireTransaction           TransactionType = "Wire"
+	SEPATransaction           TransactionType = "SEPA"
+	LetterOfCreditTransaction TransactionType = "LetterOfCredit"
+	CashTransaction           TransactionType = "Cash"
+	CryptoTransaction         TransactionType = "Crypto"
+)
+
+// TransactionData represents the structure of transactional data to be stored
+type TransactionData struct {
+	ID                 string          `json:"id"`
+	OrderNo            string          `json:"orderNo"`
+	Type               TransactionType `json:"type"`
+	Amount             Money           `json:"amount"`
+	Account            string          `json:"account"`
+	TransactionDetails string          `json:"transactionDetails"`
+	// PrivateHash is the salted hash of the account when it is kept in the
+	// paymentPrivateDetails collection rather than in the payment, see PaymentPrivateDetails
+	PrivateHash string `json:"privateHash,omitempty" metadata:",optional"`
+	// Status is the lifecycle status, CapturedAmount and RefundedAmount are set once
+	// the payment is captured or refunded
+	Status         PaymentStatus       `json:"status"`
+	CapturedAmount *Money              `json:"capturedAmount,omitempty" metadata:",optional"`
+	RefundedAmount *Money              `json:"refundedAmount,omitempty" metadata:",optional"`
+	Transitions    []PaymentTransition `json:"transitions,omitempty" metadata:",optional"`
+	CreatedAt      string              `json:"createdAt"`
+	UpdatedAt      string              `json:"updatedAt"`
+	// Add more fields as needed
+}
+
+// ShipEngineData represents the structure of ShipEngine data to be stored
+type ShipEngineData struct {
+	ID          string `json:"id"`
+	OrderNo     string `json:"orderNo"`
+	ShipmentID  string `json:"shipmentId"`
+	TrackingURL string `json:"trackingUrl"`
+	// Status is the status code of the latest tracking event, TrackingEvents the number
+	// of tracking events recorded, see GetShipmentTimeline
+	Status         string `json:"status,omitempty" metadata:",optional"`
+	TrackingEvents int    `json:"trackingEvents,omitempty" metadata:",optional"`
+	CreatedAt      string `json:"createdAt"`
+	UpdatedAt      string `json:"updatedAt"`
+	// Add more fields as needed
+}
+
+// InitOrder initializes the ledger with some sample data, kept for deployments that
+// still invoke it under this name
+func (s *SmartContract) InitOrder(ctx contractapi.TransactionContextInterface) error {
+	return s.InitLedger(ctx)
+}
+
+// InitLedger initializes the ledger with some sample data
+func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface) error {
+	// Record the transaction timestamp
+	timestamp, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	orders := []Order{
+		{OrderNo: "logis_ordr_1", Date: "2024-03-01", OrderDetail: "Sample order details 1", PaymentMethod: string(CreditCardTransaction), Status: StatusPacking},
+		{OrderNo: "logis_ordr_2", Date: "2024-03-02", OrderDetail: "Sample order details 2", PaymentMethod: string(CashTransaction), Status: StatusShipped},
+	}
+
+	for _, order := range orders {
+		order.DocType = orderObjectType
+		order.setStatus(order.Status)
+		order.CreatedAt = timestamp
+		order.UpdatedAt = timestamp
+		order.Version++
+
+		orderJSON, err := json.Marshal(order)
+		if err != nil {
+			return err
+		}
+
+		key, err := assetKey(ctx, orderObjectType, order.OrderNo)
+		if err != nil {
+			return err
+		}
+
+		err = ctx.GetStub().PutState(key, orderJSON)
+		if err != nil {
+			return internalError("failed to put order %s to world state: %v", order.OrderNo, err)
+		}
+
+		err = appendAudit(ctx, EventOrderCreated, orderObjectType, order.OrderNo, orderJSON)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateOrder validates the fields of an order along with the other arguments of the
+// caller. CreateOrder, UpdateOrder, PatchOrder and the batches share it so that they accept
+// the same orders, methods are the payment methods returned by paymentMethods
+func validateOrder(ctx contractapi.TransactionContextInterface, methods []string, date, orderDetail, paymentMethod string, args ...field) error {
+	return validate(ctx, append(args,
+		arg("date", date, required, isoDate),
+		textArg("orderDetail", orderDetail),
+		arg("paymentMethod", paymentMethod, required, oneOf(methods...)),
+	)...)
+}
+
+// CreateOrder creates a new order in the supply chain. The invoice and line items are
+// passed as OrderPrivateDetails in the transient map under "private", they are then kept
+// in the orderPrivateDetails collection and only their salted hash is recorded in the
+// order. The line items are numbered from 1 and priced by the chaincode. The payment
+// method must be an enabled transaction type of the payment rails, see GetPaymentRails
+func (s *SmartContract) CreateOrder(ctx contractapi.TransactionContextInterface, orderNo, date, orderDetail, paymentMethod string) error {
+	// Record the transaction timestamp
+	timestamp, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	logger := newLogger(ctx)
+
+	// Log the start of the function
+	logger.Debug("Creating order", "orderNo", orderNo, "date", date, "orderDetail", orderDetail, "paymentMethod", paymentMethod)
+
+	// Validate the arguments
+	methods, err := paymentMethods(ctx)
+	if err != nil {
+		return err
+	}
+	err = validateOrder(ctx, methods, date, orderDetail, paymentMethod,
+		newIDArg("orderNo", orderNo),
+	)
+	if err != nil {
+		return err
+	}
+
+	// Check if order already exists
+	exists, err := s.OrderExists(ctx, orderNo)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return alreadyExists(orderObjectType, orderNo)
+	}
+
+	private, err := orderPrivateDetails(ctx, orderNo)
+	if err != nil {
+		return err
+	}
+
+	// Create new order object
+	order := Order{
+		DocType:       orderObjectType,
+		OrderNo:       orderNo,
+		Date:          date,
+		OrderDetail:   orderDetail,
+		PaymentMethod: paymentMethod,
+	}
+	order.setStatus(StatusCreated)
+	order.CreatedAt = timestamp
+	order.UpdatedAt = timestamp
+	order.Version++
+
+	// Keep the private details out of the public world state
+	if private != nil {
+		order.PrivateHash, err = putPrivate(ctx, orderPrivateCollection, orderObjectType, orderNo, private)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Marshal order object to JSON
+	orderJSON, err := json.Marshal(order)
+	if err != nil {
+		return err
+	}
+
+	// Save order to ledger
+	key, err := assetKey(ctx, orderObjectType, orderNo)
+	if err != nil {
+		return err
+	}
+
+	err = ctx.GetStub().PutState(key, orderJSON)
+	if err != nil {
+		return internalError("failed to put order %s to world state: %v", orderNo, err)
+	}
+
+	// Publish order event
+	err = recordMutation(ctx, EventOrderCreated, orderObjectType, orderNo, nil, orderJSON)
+	if err != nil {
+		return err
+	}
+
+	// Log the success of the operation
+	logger.Info("Order created", "orderNo", orderNo, "private", private != nil)
+
+	return nil
+}
+
+// ReadOrder retrieves an order from the ledger based on its order number
+func (s *SmartContract) ReadOrder(ctx contractapi.TransactionContextInterface, orderNo string) (*Order, error) {
+	logger := newLogger(ctx)
+
+	// Log the start of the function
+	logger.Debug("Reading order", "orderNo", orderNo)
+
+	// Validate the arguments
+	err := validate(ctx,
+		idArg("orderNo", orderNo),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Retrieve order from ledger
+	key, err := assetKey(ctx, orderObjectType, orderNo)
+	if err != nil {
+		return nil, err
+	}
+
+	orderJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, internalError("failed to read order %s from world state: %v", orderNo, err)
+	}
+	if orderJSON == nil {
+		return nil, notFound(orderObjectType, orderNo)
+	}
+
+	// Unmarshal order JSON into Order struct
+	var order Order
+	err = json.Unmarshal(orderJSON, &order)
+	if err != nil {
+		return nil, internalError("failed to decode order %s: %v", orderNo, err)
+	}
+
+	// Log the success of the operation
+	logger.Info("Order read", "orderNo", orderNo)
+
+	return &order, nil
+}
+
+// UpdateOrder updates an existing order in the supply chain. Like in CreateOrder the
+// invoice and line items are passed in the transient map, the line items then replace the
+// existing ones and are numbered from 1 again. They must be passed once an order keeps
+// them private, orders recorded before keep their public invoice and line items otherwise
+func (s *SmartContract) UpdateOrder(ctx contractapi.TransactionContextInterface, orderNo, date, orderDetail, paymentMethod string) error {
+	// Record the transaction timestamp
+	timestamp, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	logger := newLogger(ctx)
+
+	// Log the start of the function
+	logger.Debug("Updating order", "orderNo", orderNo, "date", date, "orderDetail", orderDetail, "paymentMethod", paymentMethod)
+
+	// Validate the arguments
+	methods, err := paymentMethods(ctx)
+	if err != nil {
+		return err
+	}
+	err = validateOrder(ctx, methods, date, orderDetail, paymentMethod,
+		idArg("orderNo", orderNo),
+	)
+	if err != nil {
+		return err
+	}
+
+	// Retrieve existing order
+	order, err := s.ReadOrder(ctx, orderNo)
+	if err != nil {
+		return err
+	}
+
+	// Lifecycle status can only be changed through the transition functions
+	if status := order.lifecycleStatus(); isFinal(status) {
+		return finalStatus(orderObjectType, orderNo, string(status))
+	}
+
+	private, err := orderPrivateDetails(ctx, orderNo)
+	if err != nil {
+		return err
+	}
+	if private == nil && order.PrivateHash != "" {
+		return invalidArgument("the order %s keeps its invoice and line items private, pass them in the transient map under %q",
+			orderNo, privateTransientKey)
+	}
+
+	// Update existing order
+	order.Date = date
+	order.OrderDetail = orderDetail
+	order.PaymentMethod = paymentMethod
+	order.DocType = orderObjectType
+	order.UpdatedAt = timestamp
+	order.Version++
+
+	// Keep the private details out of the public world state, including those public
+	// before the order was made private
+	if private != nil {
+		order.Invoice = ""
+		order.LineItems, order.Subtotal, order.Tax, order.Total = nil, nil, nil, nil
+		order.PrivateHash, err = putPrivate(ctx, orderPrivateCollection, orderObjectType, orderNo, private)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Marshal updated order object to JSON
+	orderJSON, err := json.Marshal(order)
+	if err != nil {
+		return err
+	}
+
+	// Update order in ledger
+	key, err := assetKey(ctx, orderObjectType, orderNo)
+	if err != nil {
+		return err
+	}
+
+	previousJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return internalError("failed to read order %s from world state: %v", orderNo, err)
+	}
+
+	err = ctx.GetStub().PutState(key, orderJSON)
+	if err != nil {
+		return internalError("failed to update order %s in world state: %v", orderNo, err)
+	}
+
+	// Publish order event
+	err = recordMutation(ctx, EventOrderUpdated, orderObjectType, orderNo, previousJSON, orderJSON)
+	if err != nil {
+		return err
+	}
+
+	// Log the success of the operation
+	logger.Info("Order updated", "orderNo", orderNo)
+
+	return nil
+}
+
+// DeleteOrder deletes an order from the supply chain. Only orders without payments or
+// shipments may be deleted, the others are soft deleted with ArchiveOrder
+func (s *SmartContract) DeleteOrder(ctx contractapi.TransactionContextInterface, orderNo string) error {
+	logger := newLogger(ctx)
+
+	// Log the start of the function
+	logger.Debug("Deleting order", "orderNo", orderNo)
+
+	// Validate the arguments
+	err := validate(ctx,
+		idArg("orderNo", orderNo),
+	)
+	if err != nil {
+		return err
+	}
+
+	// Check if order exists
+	key, err := assetKey(ctx, orderObjectType, orderNo)
+	if err != nil {
+		return err
+	}
+
+	orderJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return internalError("failed to read order %s from world state: %v", orderNo, err)
+	}
+	if orderJSON == nil {
+		return notFound(orderObjectType, orderNo)
+	}
+
+	// Orders with payments or shipments are kept, see checkRetention
+	err = checkRetention(ctx, orderNo)
+	if err != nil {
+		return err
+	}
+
+	// Delete order from ledger
+	err = ctx.GetStub().DelState(key)
+	if err != nil {
+		return internalError("failed to delete order %s from world state: %v", orderNo, err)
+	}
+
+	// Delete the private details along with the order
+	var order Order
+	err = json.Unmarshal(orderJSON, &order)
+	if err != nil {
+		return internalError("failed to decode order %s: %v", orderNo, err)
+	}
+	if order.PrivateHash != "" {
+		err = ctx.GetStub().DelPrivateData(orderPrivateCollection, key)
+		if err != nil {
+			return internalError("failed to delete private details of order %s: %v", orderNo, err)
+		}
+	}
+
+	// Publish order event
+	err = recordMutation(ctx, EventOrderDeleted, orderObjectType, orderNo, orderJSON, nil)
+	if err != nil {
+		return err
+	}
+
+	// Log the success of the operation
+	logger.Info("Order deleted", "orderNo", orderNo)
+
+	return nil
+}
+
+// OrderExists checks if an order exists in the supply chain
+func (s *SmartContract) OrderExists(ctx contractapi.TransactionContextInterface, orderNo string) (bool, error) {
+	// Validate the arguments
+	err := validate(ctx,
+		idArg("orderNo", orderNo),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	// Retrieve order from ledger
+	key, err := assetKey(ctx, orderObjectType, orderNo)
+	if err != nil {
+		return false, err
+	}
+
+	orderJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return false, internalError("failed to read order %s from world state: %v", orderNo, err)
+	}
+
+	return orderJSON != nil, nil
+}
+
+// GetAllOrders returns all orders stored in the supply chain but the archived and voided
+// ones, see QueryOrdersByStatus
+func (s *SmartContract) GetAllOrders(ctx contractapi.TransactionContextInterface) ([]QueryResult, error) {
+	logger := newLogger(ctx)
+
+	// Retrieve all orders from ledger
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(orderObjectType, []string{})
+	if err != nil {
+		return nil, internalError("failed to read orders from world state: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	// Iterate over all orders, leaving out the archived ones
+	results, err := orderQueryResults(ctx, resultsIterator, nil)
+	if err != nil {
+		return nil, err
+	}
+	results = listedOrders(results)
+
+	// Log the success of the operation
+	logger.Info("Orders read", "count", len(results))
+
+	return results, nil
+}
+
+// GetOrdersWithPagination returns a page of at most pageSize orders. An empty bookmark
+// returns the first page, the bookmark of the result resumes after the returned page
+// and is empty once the last order has been returned. Archived and voided orders are
+// left out, so a page may hold fewer orders than pageSize before the last one
+func (s *SmartContract) GetOrdersWithPagination(ctx contractapi.TransactionContextInterface, pageSize int32, bookmark string) (*PaginatedQueryResult, error) {
+	logger := newLogger(ctx)
+
+	// Log parameter details
+	logger.Debug("Reading page of orders", "pageSize", pageSize, "bookmark", bookmark)
+
+	// Validate the arguments
+	err := validate(ctx,
+		intArg("pageSize", int(pageSize), atLeast(1), atMost(maxPageSize)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Retrieve one page of orders from ledger
+	resultsIterator, metadata, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(orderObjectType, []string{}, pageSize, bookmark)
+	if err != nil {
+		return nil, internalError("failed to read orders from world state: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	records, err := orderQueryResults(ctx, resultsIterator, []QueryResult{})
+	if err != nil {
+		return nil, err
+	}
+	records = listedOrders(records)
+
+	// Log the success of the operation
+	logger.Info("Orders queried", "count", len(records))
+
+	return &PaginatedQueryResult{
+		Records:             records,
+		FetchedRecordsCount: int32(len(records)),
+		Bookmark:            metadata.Bookmark,
+	}, nil
+}
+
+// orderQueryResults appends the orders returned by a world state iterator to results
+func orderQueryResults(ctx contractapi.TransactionContextInterface, resultsIterator shim.StateQueryIteratorInterface, results []QueryResult) ([]QueryResult, error) {
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, internalError("failed to read query results: %v", err)
+		}
+
+		// Unmarshal order JSON into Order struct
+		var order Order
+		err = json.Unmarshal(queryResponse.Value, &order)
+		if err != nil {
+			return nil, internalError("failed to decode order %s: %v", queryResponse.Key, err)
+		}
+
+		// Create QueryResult object and append to results
+		orderNo, err := assetID(ctx, queryResponse.Key)
+		if err != nil {
+			return nil, err
+		}
+
+		queryResult := QueryResult{Key: orderNo, Record: &order}
+		results = append(results, queryResult)
+	}
+
+	return results, nil
+}
+
+// QueryResult structure used for handling result of query
+type QueryResult struct {
+	Key    string `json:"Key"`
+	Record *Order
+}
+
+// maxPageSize is the largest page a paginated query returns
+const maxPageSize = 1000
+
+// PaginatedQueryResult structure used for handling result of a paginated query
+type PaginatedQueryResult struct {
+	Records             []QueryResult `json:"records"`
+	FetchedRecordsCount int32         `json:"fetchedRecordsCount"`
+	Bookmark            string        `json:"bookmark"`
+}
+
+// TransactionExists checks if a transaction with given ID exists in the ledger
+func (s *SmartContract) TransactionExists(ctx contractapi.TransactionContextInterface, id string) (bool, error) {
+	// Validate the arguments
+	err := validate(ctx,
+		idArg("id", id),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	key, err := assetKey(ctx, paymentObjectType, id)
+	if err != nil {
+		return false, err
+	}
+
+	dataBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return false, internalError("failed to read from world state: %v", err)
+	}
+	return dataBytes != nil, nil
+}
+
+// Init ShiptEngine
+func (s *SmartContract) InitShipEngine(ctx contractapi.TransactionContextInterface) error {
+	logger := newLogger(ctx)
+
+	// Log the success of the operation
+	logger.Info("ShipEngine chaincode initialized")
+
+	return nil
+}
+
+// ShipEngineDataExists checks if ShipEngineData with given ID exists in the ledger
+func (s *SmartContract) ShipEngineDataExists(ctx contractapi.TransactionContextInterface, id string) (bool, error) {
+	// Validate the arguments
+	err := validate(ctx,
+		idArg("id", id),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	key, err := assetKey(ctx, shipmentObjectType, id)
+	if err != nil {
+		return false, err
+	}
+
+	dataBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return false, internalError("failed to read from world state: %v", err)
+	}
+	return dataBytes != nil, nil
+}
+
+// CreateShipEngineData adds a new ShipEngineData for an existing order to the ledger
+func (s *SmartContract) CreateShipEngineData(ctx contractapi.TransactionContextInterface, id string, orderNo string, shipmentID string, trackingURL string) error {
+	// Record the transaction timestamp
+	timestamp, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	logger := newLogger(ctx)
+
+	// Log the start of the function
+	logger.Debug("Creating shipment", "id", id, "orderNo", orderNo, "shipmentId", shipmentID, "trackingUrl", trackingURL)
+
+	// Validate the arguments
+	err = validate(ctx,
+		newIDArg("id", id),
+		idArg("orderNo", orderNo),
+		arg("shipmentID", shipmentID, required, maxLength(maxIDLength)),
+		arg("trackingURL", trackingURL, maxLength(maxTextLength), matches(urlPattern, "an http or https URL")),
+	)
+	if err != nil {
+		return err
+	}
+
+	// Check if ShipEngineData already exists
+	exists, err := s.ShipEngineDataExists(ctx, id)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return alreadyExists(shipmentObjectType, id)
+	}
+
+	// Link the shipment to its order
+	err = linkToOrder(ctx, orderShipmentIndex, orderNo, id)
+	if err != nil {
+		return err
+	}
+
+	// Create new ShipEngineData object
+	data := ShipEngineData{
+		ID:          id,
+		OrderNo:     orderNo,
+		ShipmentID:  shipmentID,
+		TrackingURL: trackingURL,
+		CreatedAt:   timestamp,
+		UpdatedAt:   timestamp,
+	}
+
+	// Marshal ShipEngineData object to JSON
+	dataBytes, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	// Save ShipEngineData to ledger
+	key, err := assetKey(ctx, shipmentObjectType, id)
+	if err != nil {
+		return err
+	}
+
+	err = ctx.GetStub().PutState(key, dataBytes)
+	if err != nil {
+		return internalError("failed to put shipment %s to world state: %v", id, err)
+	}
+
+	// Publish shipment event
+	err = recordMutation(ctx, EventShipmentCreated, shipmentObjectType, id, nil, dataBytes)
+	if err != nil {
+		return err
+	}
+
+	// Log the success of the operation
+	logger.Info("Shipment created", "id", id, "orderNo", orderNo)
+
+	return nil
+}
+
+// CreateTransaction adds a new transaction for an existing order to the ledger
+// The amount is a decimal string in the given ISO 4217 currency, e.g. "100.50" and "USD"
+// The transaction type must be an enabled payment rail and the payment must follow its rules
+// The account is passed as PaymentPrivateDetails in the transient map under "private", it
+// is then kept in the paymentPrivateDetails collection. The account parameter is kept for
+// existing clients and must be empty
+func (s *SmartContract) CreateTransaction(ctx contractapi.TransactionContextInterface, id string, orderNo string, transactionTypeStr string, amount string, currency string, account string, transactionDetails string) error {
+	// Record the transaction timestamp
+	timestamp, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	logger := newLogger(ctx)
+
+	// Log the start of the function
+	logger.Debug("Creating payment", "id", id, "orderNo", orderNo, "transactionType", transactionTypeStr, "amount", amount, "currency", currency, "account", account, "transactionDetails", transactionDetails)
+
+	// Validate the arguments
+	err = validate(ctx,
+		newIDArg("id", id),
+		idArg("orderNo", orderNo),
+		arg("transactionType", transactionTypeStr, required),
+		arg("amount", amount, required, positiveAmount(currency)),
+		arg("currency", currency, required, matches(currencyPattern, "an ISO 4217 currency code such as USD")),
+		privateArg("account", account != ""),
+		textArg("transactionDetails", transactionDetails),
+	)
+	if err != nil {
+		return err
+	}
+
+	rail, err := paymentRail(ctx, transactionTypeStr)
+	if err != nil {
+		return err
+	}
+
+	money, err := ParseMoney(amount, currency)
+	if err != nil {
+		return wrapError(err, CodeInvalidArgument, "invalid amount for transaction %s", id)
+	}
+
+	private, err := paymentPrivateDetails(ctx, id)
+	if err != nil {
+		return err
+	}
+	railAccount := ""
+	if private != nil {
+		railAccount = private.Account
+	}
+
+	err = rail.check(railAccount, money, transactionDetails)
+	if err != nil {
+		return wrapError(err, CodeInvalidArgument, "invalid transaction %s", id)
+	}
+
+	// Check if transaction already exists
+	exists, err := s.TransactionExists(ctx, id)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return alreadyExists(paymentObjectType, id)
+	}
+
+	// Link the transaction to its order
+	err = linkToOrder(ctx, orderPaymentIndex, orderNo, id)
+	if err != nil {
+		return err
+	}
+
+	// Create new TransactionData object
+	data := TransactionData{
+		ID:                 id,
+		OrderNo:            orderNo,
+		Type:               rail.Type,
+		Amount:             money,
+		TransactionDetails: transactionDetails,
+		Status:             PaymentPending,
+		CreatedAt:          timestamp,
+		UpdatedAt:          timestamp,
+	}
+
+	// Keep the private details out of the public world state
+	if private != nil {
+		data.PrivateHash, err = putPrivate(ctx, paymentPrivateCollection, paymentObjectType, id, private)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Marshal TransactionData object to JSON
+	dataBytes, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	// Save TransactionData to ledger
+	key, err := assetKey(ctx, paymentObjectType, id)
+	if err != nil {
+		return err
+	}
+
+	err = ctx.GetStub().PutState(key, dataBytes)
+	if err != nil {
+		return internalError("failed to put payment %s to world state: %v", id, err)
+	}
+
+	// Publish payment event
+	err = recordMutation(ctx, EventPaymentCreated, paymentObjectType, id, nil, dataBytes)
+	if err != nil {
+		return err
+	}
+
+	// Log the success of the operation
+	logger.Info("Payment created", "id", id, "orderNo", orderNo, "private", private != nil)
+
+	return nil
+}
+
+// GetTransaction retrieves transaction from the ledger based on ID
+func (s *SmartContract) GetTransaction(ctx contractapi.TransactionContextInterface, id string) (*TransactionData, error) {
+	logger := newLogger(ctx)
+
+	// Log the start of the function
+	logger.Debug("Reading payment", "id", id)
+
+	// Validate the arguments
+	err := validate(ctx,
+		idArg("id", id),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Retrieve transaction from ledger
+	key, err := assetKey(ctx, paymentObjectType, id)
+	if err != nil {
+		return nil, err
+	}
+
+	dataBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, internalError("failed to read from world state: %v", err)
+	}
+	if dataBytes == nil {
+		return nil, notFound(paymentObjectType, id)
+	}
+
+	// Unmarshal TransactionData JSON into struct
+	var data TransactionData
+	err = json.Unmarshal(dataBytes, &data)
+	if err != nil {
+		return nil, internalError("failed to decode payment %s: %v", id, err)
+	}
+
+	// Log the success of the operation
+	logger.Info("Payment read", "id", id)
+
+	return &data, nil
+}
@@ -0,0 +1,444 @@
+package ordermanagement
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func TestNewChaincode(t *testing.T) {
+	if _, err := contractapi.NewChaincode(NewSmartContract()); err != nil {
+		t.Fatalf("failed to create chaincode: %v", err)
+	}
+}
+
+func TestInitLedger(t *testing.T) {
+	for _, function := range []string{"InitLedger", "InitOrder"} {
+		t.Run(function, func(t *testing.T) {
+			env := newTestEnv(t)
+			env.mustSubmit(RoleAdmin, function, func(ctx contractapi.TransactionContextInterface) error {
+				if function == "InitOrder" {
+					return env.contract.InitOrder(ctx)
+				}
+				return env.contract.InitLedger(ctx)
+			})
+
+			order := env.readOrder("logis_ordr_2")
+			if order.Status != StatusShipped || order.OrderTrack != "Shipped" || order.PackingStatus != "Packed" {
+				t.Errorf("unexpected sample order status: %+v", order)
+			}
+			if order.CreatedAt != testStart.Format(time.RFC3339) {
+				t.Errorf("createdAt = %s, want the transaction time", order.CreatedAt)
+			}
+
+			var entries []AuditEntry
+			env.mustSubmit(RoleAuditor, "GetAuditTrail", func(ctx contractapi.TransactionContextInterface) error {
+				var err error
+				entries, err = env.contract.GetAuditTrail(ctx, "order:logis_ordr_1")
+				return err
+			})
+			if len(entries) != 1 {
+				t.Errorf("got %d audit entries, want 1", len(entries))
+			}
+		})
+	}
+}
+
+func TestInitShipEngine(t *testing.T) {
+	env := newTestEnv(t)
+	env.mustSubmit(RoleAdmin, "InitShipEngine", func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.InitShipEngine(ctx)
+	})
+}
+
+func TestCreateOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(env *testEnv)
+		orderNo string
+		wantErr string
+	}{
+		{name: "new order", orderNo: "ORD-1"},
+		{name: "duplicate order", setup: func(env *testEnv) { env.createOrder("ORD-1") }, orderNo: "ORD-1", wantErr: "already exists"},
+		{
+			name: "payment with the same ID",
+			setup: func(env *testEnv) {
+				env.createOrder("ORD-0")
+				env.createPayment("ORD-1", "ORD-0")
+			},
+			orderNo: "ORD-1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			if tt.setup != nil {
+				tt.setup(env)
+			}
+
+			err := env.submit(RoleSeller, "CreateOrder", func(ctx contractapi.TransactionContextInterface) error {
+				return env.contract.CreateOrder(ctx, tt.orderNo, "2024-03-01", "10 pallets", "CreditCard")
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			order := env.readOrder(tt.orderNo)
+			if order.Status != StatusCreated || order.Invoice != "" || order.PrivateHash != "" {
+				t.Errorf("unexpected order: %+v", order)
+			}
+			if order.CreatedAt == "" || order.CreatedAt != order.UpdatedAt {
+				t.Errorf("createdAt %q and updatedAt %q should both be the creation time", order.CreatedAt, order.UpdatedAt)
+			}
+		})
+	}
+}
+
+func TestCreateOrderEvent(t *testing.T) {
+	env := newTestEnv(t)
+	env.createOrder("ORD-1")
+
+	event := env.lastEvent()
+	var payload AssetEvent
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		t.Fatalf("invalid event payload: %v", err)
+	}
+
+	if event.Name != EventOrderCreated || payload.Name != EventOrderCreated {
+		t.Errorf("event name = %s, want %s", event.Name, EventOrderCreated)
+	}
+	if payload.Version != eventVersion || payload.AssetType != orderObjectType || payload.Key != "ORD-1" || payload.TxID != event.TxID {
+		t.Errorf("unexpected event payload: %+v", payload)
+	}
+	if payload.PreviousDigest != "" || payload.NewDigest == "" {
+		t.Errorf("a creation should only have a new digest: %+v", payload)
+	}
+	if payload.Actor != testIdentity(RoleSeller).ID || payload.MSPID != "Org1MSP" {
+		t.Errorf("unexpected actor %s of %s", payload.Actor, payload.MSPID)
+	}
+}
+
+func TestReadOrder(t *testing.T) {
+	env := newTestEnv(t)
+	env.createOrder("ORD-1")
+
+	if order := env.readOrder("ORD-1"); order.OrderNo != "ORD-1" {
+		t.Errorf("read order %s, want ORD-1", order.OrderNo)
+	}
+
+	err := env.submit(RoleAuditor, "ReadOrder", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.contract.ReadOrder(ctx, "ORD-2")
+		return err
+	})
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("got error %v, want a missing order error", err)
+	}
+}
+
+func TestUpdateOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(env *testEnv)
+		orderNo string
+		wantErr string
+	}{
+		{name: "created order", setup: func(env *testEnv) { env.createOrder("ORD-1") }, orderNo: "ORD-1"},
+		{name: "missing order", orderNo: "ORD-1", wantErr: "does not exist"},
+		{
+			name: "cancelled order",
+			setup: func(env *testEnv) {
+				env.createOrder("ORD-1")
+				env.mustSubmit(RoleBuyer, "CancelOrder", func(ctx contractapi.TransactionContextInterface) error {
+					return env.contract.CancelOrder(ctx, "ORD-1", "duplicate")
+				})
+			},
+			orderNo: "ORD-1",
+			wantErr: "can no longer be updated",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			if tt.setup != nil {
+				tt.setup(env)
+			}
+
+			err := env.submit(RoleSeller, "UpdateOrder", func(ctx contractapi.TransactionContextInterface) error {
+				return env.contract.UpdateOrder(ctx, tt.orderNo, "2024-03-05", "12 pallets", "Cash")
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			order := env.readOrder(tt.orderNo)
+			if order.OrderDetail != "12 pallets" || order.PaymentMethod != "Cash" || order.Status != StatusCreated {
+				t.Errorf("unexpected order: %+v", order)
+			}
+			if order.UpdatedAt == order.CreatedAt {
+				t.Errorf("updatedAt was not moved to the update time")
+			}
+			if event := env.lastEvent(); event.Name != EventOrderUpdated {
+				t.Errorf("event = %s, want %s", event.Name, EventOrderUpdated)
+			}
+		})
+	}
+}
+
+func TestDeleteOrder(t *testing.T) {
+	env := newTestEnv(t)
+	env.createOrder("ORD-1")
+
+	env.mustSubmit(RoleSeller, "DeleteOrder", func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.DeleteOrder(ctx, "ORD-1")
+	})
+
+	var payload AssetEvent
+	if err := json.Unmarshal(env.lastEvent().Payload, &payload); err != nil {
+		t.Fatalf("invalid event payload: %v", err)
+	}
+	if payload.Name != EventOrderDeleted || payload.PreviousDigest == "" || payload.NewDigest != "" {
+		t.Errorf("unexpected event payload: %+v", payload)
+	}
+
+	err := env.submit(RoleSeller, "DeleteOrder", func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.DeleteOrder(ctx, "ORD-1")
+	})
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("got error %v, want a missing order error", err)
+	}
+}
+
+func TestOrderExists(t *testing.T) {
+	env := newTestEnv(t)
+	env.createOrder("ORD-1")
+
+	for orderNo, want := range map[string]bool{"ORD-1": true, "ORD-2": false} {
+		var exists bool
+		env.mustSubmit(RoleAuditor, "OrderExists", func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			exists, err = env.contract.OrderExists(ctx, orderNo)
+			return err
+		})
+		if exists != want {
+			t.Errorf("OrderExists(%s) = %t, want %t", orderNo, exists, want)
+		}
+	}
+}
+
+func TestGetAllOrders(t *testing.T) {
+	env := newTestEnv(t)
+	env.createOrder("ORD-1")
+	env.createOrder("ORD-2")
+	env.createPayment("PAY-1", "ORD-1")
+	env.createShipment("SHP-1", "ORD-1")
+
+	var results []QueryResult
+	env.mustSubmit(RoleAuditor, "GetAllOrders", func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		results, err = env.contract.GetAllOrders(ctx)
+		return err
+	})
+
+	if len(results) != 2 {
+		t.Fatalf("got %d orders, want 2", len(results))
+	}
+	for i, orderNo := range []string{"ORD-1", "ORD-2"} {
+		if results[i].Key != orderNo || results[i].Record.OrderNo != orderNo {
+			t.Errorf("result %d = %s, want %s", i, results[i].Key, orderNo)
+		}
+	}
+}
+
+func TestGetOrdersWithPagination(t *testing.T) {
+	env := newTestEnv(t)
+	for _, orderNo := range []string{"ORD-1", "ORD-2", "ORD-3", "ORD-4", "ORD-5"} {
+		env.createOrder(orderNo)
+	}
+
+	page := func(pageSize int32, bookmark string) (*PaginatedQueryResult, error) {
+		var result *PaginatedQueryResult
+		err := env.submit(RoleAuditor, "GetOrdersWithPagination", func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			result, err = env.contract.GetOrdersWithPagination(ctx, pageSize, bookmark)
+			return err
+		})
+		return result, err
+	}
+
+	var orderNos []string
+	bookmark := ""
+	for pages := 0; pages == 0 || bookmark != ""; pages++ {
+		if pages == 3 {
+			t.Fatal("paging did not end after 3 pages")
+		}
+		result, err := page(2, bookmark)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if int(result.FetchedRecordsCount) != len(result.Records) {
+			t.Errorf("fetched count %d does not match %d records", result.FetchedRecordsCount, len(result.Records))
+		}
+		for _, record := range result.Records {
+			orderNos = append(orderNos, record.Key)
+		}
+		bookmark = result.Bookmark
+	}
+
+	if strings.Join(orderNos, ",") != "ORD-1,ORD-2,ORD-3,ORD-4,ORD-5" {
+		t.Errorf("paged through %v", orderNos)
+	}
+
+	for _, pageSize := range []int32{0, -1, maxPageSize + 1} {
+		if _, err := page(pageSize, ""); err == nil {
+			t.Errorf("page size %d was accepted", pageSize)
+		}
+	}
+
+	empty := newTestEnv(t)
+	var result *PaginatedQueryResult
+	empty.mustSubmit(RoleAuditor, "GetOrdersWithPagination", func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		result, err = empty.contract.GetOrdersWithPagination(ctx, 10, "")
+		return err
+	})
+	if result.Records == nil || len(result.Records) != 0 || result.Bookmark != "" {
+		t.Errorf("unexpected page of an empty ledger: %+v", result)
+	}
+}
+
+func TestCreateTransaction(t *testing.T) {
+	env := newTestEnv(t)
+	env.createOrder("ORD-1")
+	create := func() error {
+		return env.submitPrivate(RoleBank, "CreateTransaction", paymentPrivate, func(ctx contractapi.TransactionContextInterface) error {
+			return env.contract.CreateTransaction(ctx, "PAY-1", "ORD-1", "ACH", "100.50", "USD", "", "deposit")
+		})
+	}
+
+	if err := create(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if event := env.lastEvent(); event.Name != EventPaymentCreated {
+		t.Errorf("event = %s, want %s", event.Name, EventPaymentCreated)
+	}
+	if err := create(); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("got error %v, want a duplicate transaction error", err)
+	}
+
+	var data *TransactionData
+	env.mustSubmit(RoleAuditor, "GetTransaction", func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		data, err = env.contract.GetTransaction(ctx, "PAY-1")
+		return err
+	})
+	if data.Type != ACHTransaction || data.Amount != (Money{Amount: 10050, Currency: "USD"}) || data.OrderNo != "ORD-1" || data.CreatedAt == "" {
+		t.Errorf("unexpected transaction: %+v", data)
+	}
+
+	err := env.submit(RoleAuditor, "GetTransaction", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.contract.GetTransaction(ctx, "PAY-2")
+		return err
+	})
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("got error %v, want a missing transaction error", err)
+	}
+
+	invalid := []struct{ orderNo, amount, currency string }{
+		{"ORD-1", "100.505", "USD"},
+		{"ORD-1", "100.5", "JPY"},
+		{"ORD-1", "1e3", "USD"},
+		{"ORD-1", "0", "USD"},
+		{"ORD-1", "-5", "USD"},
+		{"ORD-1", "100", "usd"},
+		{"ORD-2", "100", "USD"},
+		{"", "100", "USD"},
+	}
+	for _, tt := range invalid {
+		err := env.submitPrivate(RoleBank, "CreateTransaction", paymentPrivate, func(ctx contractapi.TransactionContextInterface) error {
+			return env.contract.CreateTransaction(ctx, "PAY-3", tt.orderNo, "ACH", tt.amount, tt.currency, "", "deposit")
+		})
+		if err == nil {
+			t.Errorf("amount %s %s for order %q was accepted", tt.amount, tt.currency, tt.orderNo)
+		}
+	}
+}
+
+func TestGetLegacyTransaction(t *testing.T) {
+	env := newTestEnv(t)
+	key, _ := env.stub.CreateCompositeKey(paymentObjectType, []string{"PAY-1"})
+	env.stub.PutCommittedState(key, []byte(`{"id":"PAY-1","type":"ACH","amount":100.5,"account":"1234567890"}`))
+
+	var data *TransactionData
+	env.mustSubmit(RoleAuditor, "GetTransaction", func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		data, err = env.contract.GetTransaction(ctx, "PAY-1")
+		return err
+	})
+	if data.Amount != (Money{10050, "USD"}) {
+		t.Errorf("legacy amount read as %+v", data.Amount)
+	}
+}
+
+func TestTransactionExists(t *testing.T) {
+	env := newTestEnv(t)
+	env.createOrder("PAY-1")
+
+	var exists bool
+	env.mustSubmit(RoleAuditor, "TransactionExists", func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		exists, err = env.contract.TransactionExists(ctx, "PAY-1")
+		return err
+	})
+	if exists {
+		t.Error("an order must not be reported as an existing transaction")
+	}
+}
+
+func TestCreateShipEngineData(t *testing.T) {
+	env := newTestEnv(t)
+	env.createOrder("ORD-1")
+	create := func() error {
+		return env.submit(RoleCarrier, "CreateShipEngineData", func(ctx contractapi.TransactionContextInterface) error {
+			return env.contract.CreateShipEngineData(ctx, "SHP-1", "ORD-1", "se-1", "https://track.example.com/se-1")
+		})
+	}
+
+	if err := create(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if event := env.lastEvent(); event.Name != EventShipmentCreated {
+		t.Errorf("event = %s, want %s", event.Name, EventShipmentCreated)
+	}
+	if err := create(); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("got error %v, want a duplicate shipment error", err)
+	}
+
+	for id, want := range map[string]bool{"SHP-1": true, "SHP-2": false} {
+		var exists bool
+		env.mustSubmit(RoleAuditor, "ShipEngineDataExists", func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			exists, err = env.contract.ShipEngineDataExists(ctx, id)
+			return err
+		})
+		if exists != want {
+			t.Errorf("ShipEngineDataExists(%s) = %t, want %t", id, exists, want)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package ordermanagement
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrorCode is the stable, machine-readable class of a contract error
+type ErrorCode string
+
+// Error codes returned by the contract functions
+const (
+	CodeNotFound               ErrorCode = "NOT_FOUND"
+	CodeAlreadyExists          ErrorCode = "ALREADY_EXISTS"
+	CodeInvalidArgument        ErrorCode = "INVALID_ARGUMENT"
+	CodeForbidden              ErrorCode = "FORBIDDEN"
+	CodeInvalidStateTransition ErrorCode = "INVALID_STATE_TRANSITION"
+	CodeConflict               ErrorCode = "CONFLICT"
+	CodeInternal               ErrorCode = "INTERNAL"
+)
+
+// ContractError is the error returned by the contract functions. Its message is the JSON
+// of the error, so clients can branch on the code and read the details without parsing
+// text. Errors lists the rejected arguments of an INVALID_ARGUMENT error from validation
+// and Items the outcome of every item of a rejected batch
+type ContractError struct {
+	Code    ErrorCode         `json:"code"`
+	Message string            `json:"message"`
+	Details map[string]string `json:"details,omitempty"`
+	Errors  []FieldError      `json:"errors,omitempty"`
+	Items   []BatchItemResult `json:"items,omitempty"`
+}
+
+func (e *ContractError) Error() string {
+	errorJSON, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Sprintf("%s: %s", e.Code, e.Message)
+	}
+	return string(errorJSON)
+}
+
+// codedError is implemented by the errors that carry an error code
+type codedError interface {
+	error
+	contractError() *ContractError
+}
+
+func (e *ContractError) contractError() *ContractError {
+	return e
+}
+
+// newError returns an error with the given code, details and message
+func newError(code ErrorCode, details map[string]string, format string, args ...interface{}) *ContractError {
+	return &ContractError{Code: code, Message: fmt.Sprintf(format, args...), Details: details}
+}
+
+// notFound reports a missing asset
+func notFound(objectType string, id string) *ContractError {
+	return newError(CodeNotFound, map[string]string{"type": objectType, "id": id}, "the %s %s does not exist", objectType, id)
+}
+
+// alreadyExists reports an asset created twice
+func alreadyExists(objectType string, id string) *ContractError {
+	return newError(CodeAlreadyExists, map[string]string{"type": objectType, "id": id}, "the %s %s already exists", objectType, id)
+}
+
+// invalidTransition reports a move between two statuses the lifecycle of an asset does not allow
+func invalidTransition(objectType string, id string, from string, to string) *ContractError {
+	return newError(CodeInvalidStateTransition, map[string]string{"type": objectType, "id": id, "from": from, "to": to},
+		"the %s %s cannot move from %s to %s", objectType, id, from, to)
+}
+
+// finalStatus reports a change to an asset whose lifecycle has ended
+func finalStatus(objectType string, id string, status string) *ContractError {
+	return newError(CodeInvalidStateTransition, map[string]string{"type": objectType, "id": id, "from": status},
+		"the %s %s is %s and can no longer be updated", objectType, id, status)
+}
+
+// invalidArgument reports an argument rejected by the rules of the contract
+func invalidArgument(format string, args ...interface{}) *ContractError {
+	return newError(CodeInvalidArgument, nil, format, args...)
+}
+
+// internalError reports a failure of the ledger or of data read from it
+func internalError(format string, args ...interface{}) *ContractError {
+	return newError(CodeInternal, nil, format, args...)
+}
+
+// asContractError returns the coded form of an error, errors without a code are reported
+// with the given one
+func asContractError(err error, code ErrorCode) *ContractError {
+	var coded codedError
+	if errors.As(err, &coded) {
+		return coded.contractError()
+	}
+	return &ContractError{Code: code, Message: err.Error()}
+}
+
+// wrapError prefixes the message of an error with its context, keeping its code and
+// details. Errors without a code are reported with the given one
+func wrapError(err error, code ErrorCode, format string, args ...interface{}) error {
+	wrapped := *asContractError(err, code)
+	wrapped.Message = fmt.Sprintf(format, args...) + ": " + wrapped.Message
+	return &wrapped
+}
+
+// errorCode returns the code of an error, or the empty code when it has none
+func errorCode(err error) ErrorCode {
+	var coded codedError
+	if errors.As(err, &coded) {
+		return coded.contractError().Code
+	}
+	return ""
+}
@@ -0,0 +1,94 @@
+package ordermanagement
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// decodeError decodes the JSON message of a contract error
+func decodeError(t *testing.T, err error) ContractError {
+	t.Helper()
+	if err == nil {
+		t.Fatal("got no error")
+	}
+	var decoded ContractError
+	if jsonErr := json.Unmarshal([]byte(err.Error()), &decoded); jsonErr != nil {
+		t.Fatalf("error %q is not JSON: %v", err, jsonErr)
+	}
+	return decoded
+}
+
+func TestErrorCodes(t *testing.T) {
+	env := newTestEnv(t)
+	env.createOrder("ORD-1")
+	env.createPayment("PAY-1", "ORD-1")
+
+	tests := []struct {
+		name    string
+		role    string
+		fn      string
+		call    func(ctx contractapi.TransactionContextInterface) error
+		code    ErrorCode
+		details map[string]string
+	}{
+		{"missing order", RoleAuditor, "ReadOrder", func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.contract.ReadOrder(ctx, "ORD-2")
+			return err
+		}, CodeNotFound, map[string]string{"type": "order", "id": "ORD-2"}},
+		{"missing payment", RoleAuditor, "GetTransaction", func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.contract.GetTransaction(ctx, "PAY-2")
+			return err
+		}, CodeNotFound, map[string]string{"type": "payment", "id": "PAY-2"}},
+		{"order created twice", RoleSeller, "CreateOrder", func(ctx contractapi.TransactionContextInterface) error {
+			return env.contract.CreateOrder(ctx, "ORD-1", "2024-03-01", "10 pallets", "ACH")
+		}, CodeAlreadyExists, map[string]string{"type": "order", "id": "ORD-1"}},
+		{"invalid argument", RoleSeller, "CreateOrder", func(ctx contractapi.TransactionContextInterface) error {
+			return env.contract.CreateOrder(ctx, "ORD-2", "2024-03-01", "10 pallets", "Barter")
+		}, CodeInvalidArgument, map[string]string{"function": "CreateOrder"}},
+		{"role not allowed", RoleBuyer, "CreateOrder", func(ctx contractapi.TransactionContextInterface) error {
+			return nil
+		}, CodeForbidden, map[string]string{"function": "CreateOrder", "mspId": "Org1MSP", "role": RoleBuyer}},
+		{"skipped status", RoleCarrier, "MarkDelivered", func(ctx contractapi.TransactionContextInterface) error {
+			return env.contract.MarkDelivered(ctx, "ORD-1")
+		}, CodeInvalidStateTransition, map[string]string{"type": "order", "id": "ORD-1", "from": string(StatusCreated), "to": string(StatusDelivered)}},
+		{"referenced order", RoleSeller, "DeleteOrder", func(ctx contractapi.TransactionContextInterface) error {
+			return env.contract.DeleteOrder(ctx, "ORD-1")
+		}, CodeConflict, map[string]string{"type": "order", "id": "ORD-1", "referencedBy": "PAY-1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := env.submit(tt.role, tt.fn, tt.call)
+			decoded := decodeError(t, err)
+			if decoded.Code != tt.code || errorCode(err) != tt.code {
+				t.Errorf("code = %s, want %s", decoded.Code, tt.code)
+			}
+			if decoded.Message == "" {
+				t.Error("error has no message")
+			}
+			if !reflect.DeepEqual(decoded.Details, tt.details) {
+				t.Errorf("details = %v, want %v", decoded.Details, tt.details)
+			}
+		})
+	}
+}
+
+func TestWrapError(t *testing.T) {
+	err := wrapError(notFound(orderObjectType, "ORD-1"), CodeInvalidArgument, "invalid payment %s", "PAY-1")
+	decoded := decodeError(t, err)
+	if decoded.Code != CodeNotFound || decoded.Message != "invalid payment PAY-1: the order ORD-1 does not exist" || decoded.Details["id"] != "ORD-1" {
+		t.Errorf("unexpected wrapped error: %+v", decoded)
+	}
+
+	err = wrapError(errors.New("the amount is out of range"), CodeInvalidArgument, "invalid payment %s", "PAY-1")
+	if code := errorCode(err); code != CodeInvalidArgument {
+		t.Errorf("code = %s, want %s", code, CodeInvalidArgument)
+	}
+	if code := errorCode(fmt.Errorf("plain")); code != "" {
+		t.Errorf("plain error has code %s", code)
+	}
+}
@@ -0,0 +1,130 @@
+package ordermanagement
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// eventVersion is the version of the event payload layout, bumped on breaking changes
+const eventVersion = 1
+
+// Names of the chaincode events emitted on mutations
+const (
+	EventOrderCreated         = "OrderCreated"
+	EventOrderUpdated         = "OrderUpdated"
+	EventOrderDeleted         = "OrderDeleted"
+	EventOrderStatusChanged   = "OrderStatusChanged"
+	EventOrderArchived        = "OrderArchived"
+	EventOrderVoided          = "OrderVoided"
+	EventOrderRestored        = "OrderRestored"
+	EventOrdersBatchCreated   = "OrdersBatchCreated"
+	EventOrdersBatchUpdated   = "OrdersBatchUpdated"
+	EventOrderLineItemAdded   = "OrderLineItemAdded"
+	EventOrderLineItemAmended = "OrderLineItemAmended"
+	EventOrderLineItemRemoved = "OrderLineItemRemoved"
+	EventPaymentCreated       = "PaymentCreated"
+	EventPaymentStatusChanged = "PaymentStatusChanged"
+	EventPaymentRailChanged   = "PaymentRailChanged"
+	EventShipmentCreated      = "ShipmentCreated"
+	EventTrackingEventAdded   = "TrackingEventAdded"
+	EventSettingsChanged      = "SettingsChanged"
+)
+
+// AssetEvent is the payload of the chaincode event emitted for every mutation. The
+// digests are the SHA-256 of the asset JSON before and after the mutation, empty
+// when the asset did not exist before or no longer exists after
+type AssetEvent struct {
+	Version        int    `json:"version"`
+	Name           string `json:"name"`
+	AssetType      string `json:"assetType"`
+	Key            string `json:"key"`
+	PreviousDigest string `json:"previousDigest"`
+	NewDigest      string `json:"newDigest"`
+	Actor          string `json:"actor"`
+	MSPID          string `json:"mspId"`
+	TxID           string `json:"txId"`
+}
+
+// BatchEvent is the payload of the chaincode event emitted for a batch, which lists the
+// keys of the assets it changed rather than their digests
+type BatchEvent struct {
+	Version   int      `json:"version"`
+	Name      string   `json:"name"`
+	AssetType string   `json:"assetType"`
+	Keys      []string `json:"keys"`
+	Actor     string   `json:"actor"`
+	MSPID     string   `json:"mspId"`
+	TxID      string   `json:"txId"`
+}
+
+// stateDigest returns the hex encoded SHA-256 of an asset JSON, or an empty string for no asset
+func stateDigest(value []byte) string {
+	if value == nil {
+		return ""
+	}
+	sum := sha256.Sum256(value)
+	return hex.EncodeToString(sum[:])
+}
+
+// emitEvent sets the chaincode event describing the mutation of an asset from its
+// previous to its new JSON. Fabric keeps a single event per transaction
+func emitEvent(ctx contractapi.TransactionContextInterface, name string, assetType string, id string, previous []byte, current []byte) error {
+	actor, _ := ctx.GetClientIdentity().GetID()
+	mspID, _ := ctx.GetClientIdentity().GetMSPID()
+
+	event := AssetEvent{
+		Version:        eventVersion,
+		Name:           name,
+		AssetType:      assetType,
+		Key:            id,
+		PreviousDigest: stateDigest(previous),
+		NewDigest:      stateDigest(current),
+		Actor:          actor,
+		MSPID:          mspID,
+		TxID:           ctx.GetStub().GetTxID(),
+	}
+
+	eventJSON, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	err = ctx.GetStub().SetEvent(name, eventJSON)
+	if err != nil {
+		return internalError("failed to set event %s for %s %s: %v", name, assetType, id, err)
+	}
+
+	return nil
+}
+
+// emitBatchEvent sets the chaincode event describing a batch of mutations of assets of
+// the same type
+func emitBatchEvent(ctx contractapi.TransactionContextInterface, name string, assetType string, ids []string) error {
+	actor, _ := ctx.GetClientIdentity().GetID()
+	mspID, _ := ctx.GetClientIdentity().GetMSPID()
+
+	event := BatchEvent{
+		Version:   eventVersion,
+		Name:      name,
+		AssetType: assetType,
+		Keys:      ids,
+		Actor:     actor,
+		MSPID:     mspID,
+		TxID:      ctx.GetStub().GetTxID(),
+	}
+
+	eventJSON, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	err = ctx.GetStub().SetEvent(name, eventJSON)
+	if err != nil {
+		return internalError("failed to set event %s for %d %s assets: %v", name, len(ids), assetType, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,349 @@
+package ordermanagement
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// GetHistoryForKey returns the history of changes for a given order number. See
+// GetOrderHistory for the changes between versions and who made them
+func (s *SmartContract) GetHistoryForKey(ctx contractapi.TransactionContextInterface, orderNo string) ([]HistoryQueryResult, error) {
+	logger := newLogger(ctx)
+
+	// Log the start of the function
+	logger.Debug("Reading order history", "orderNo", orderNo)
+
+	// Validate the arguments
+	err := validate(ctx,
+		idArg("orderNo", orderNo),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := assetKey(ctx, orderObjectType, orderNo)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetHistoryForKey(key)
+	if err != nil {
+		return nil, internalError("failed to get history for order %s: %v", orderNo, err)
+	}
+	defer resultsIterator.Close()
+
+	var history []HistoryQueryResult
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, internalError("failed to read query results: %v", err)
+		}
+
+		var order Order
+		if queryResponse.IsDelete {
+			order = Order{}
+		} else {
+			err = json.Unmarshal(queryResponse.Value, &order)
+			if err != nil {
+				return nil, internalError("failed to decode order %s: %v", orderNo, err)
+			}
+		}
+
+		historyQueryResult := HistoryQueryResult{
+			TxId:      queryResponse.TxId,
+			Timestamp: historyTimestamp(queryResponse.Timestamp),
+			IsDelete:  queryResponse.IsDelete,
+			Order:     order,
+		}
+		history = append(history, historyQueryResult)
+	}
+
+	// Log the success of the operation
+	logger.Info("Order history read", "orderNo", orderNo, "entries", len(history))
+
+	return history, nil
+}
+
+// HistoryQueryResult structure used for handling result of history query
+type HistoryQueryResult struct {
+	TxId      string `json:"txId"`
+	Timestamp string `json:"timestamp"`
+	IsDelete  bool   `json:"isDelete"`
+	Order     Order  `json:"order"`
+}
+
+// HistoryEntry is a version of an order, payment or shipment together with the fields it
+// changed and the identity of the caller who wrote it. Actor, MSPID and Action come from
+// the audit trail and are empty for versions written before it was kept
+type HistoryEntry struct {
+	TxID      string        `json:"txId"`
+	Timestamp string        `json:"timestamp"`
+	IsDelete  bool          `json:"isDelete"`
+	Actor     string        `json:"actor,omitempty" metadata:",optional"`
+	MSPID     string        `json:"mspId,omitempty" metadata:",optional"`
+	Action    string        `json:"action,omitempty" metadata:",optional"`
+	Changes   []FieldChange `json:"changes"`
+	// Only the asset the history is read for is set, and none for a delete
+	Order    *Order           `json:"order,omitempty" metadata:",optional"`
+	Payment  *TransactionData `json:"payment,omitempty" metadata:",optional"`
+	Shipment *ShipEngineData  `json:"shipment,omitempty" metadata:",optional"`
+}
+
+// FieldChange is a field that differs between a version and the one before it. Nested
+// fields are named by their path, e.g. amount.amount or lineItems.0.quantity. Values
+// are JSON encoded and empty when the field is absent from that version
+type FieldChange struct {
+	Field    string `json:"field"`
+	Previous string `json:"previous,omitempty" metadata:",optional"`
+	Current  string `json:"current,omitempty" metadata:",optional"`
+}
+
+// GetOrderHistory returns the versions of an order newest first. from and to optionally
+// restrict the versions to those written in that window, both given as RFC3339 and
+// inclusive. The changes of a version are always against the version before it, even
+// when that one falls outside the window
+func (s *SmartContract) GetOrderHistory(ctx contractapi.TransactionContextInterface, orderNo string, from string, to string) ([]HistoryEntry, error) {
+	return readHistory(ctx, orderObjectType, "orderNo", orderNo, from, to, func(value []byte, entry *HistoryEntry) error {
+		entry.Order = &Order{}
+		return json.Unmarshal(value, entry.Order)
+	})
+}
+
+// GetPaymentHistory returns the versions of a payment newest first, see GetOrderHistory
+func (s *SmartContract) GetPaymentHistory(ctx contractapi.TransactionContextInterface, id string, from string, to string) ([]HistoryEntry, error) {
+	return readHistory(ctx, paymentObjectType, "id", id, from, to, func(value []byte, entry *HistoryEntry) error {
+		entry.Payment = &TransactionData{}
+		return json.Unmarshal(value, entry.Payment)
+	})
+}
+
+// GetShipmentHistory returns the versions of a shipment newest first, see GetOrderHistory
+func (s *SmartContract) GetShipmentHistory(ctx contractapi.TransactionContextInterface, id string, from string, to string) ([]HistoryEntry, error) {
+	return readHistory(ctx, shipmentObjectType, "id", id, from, to, func(value []byte, entry *HistoryEntry) error {
+		entry.Shipment = &ShipEngineData{}
+		return json.Unmarshal(value, entry.Shipment)
+	})
+}
+
+// readHistory reads the versions of an asset written in the window, decoding each one
+// into its entry with decode
+func readHistory(ctx contractapi.TransactionContextInterface, objectType string, idName string, id string, from string, to string, decode func(value []byte, entry *HistoryEntry) error) ([]HistoryEntry, error) {
+	logger := newLogger(ctx)
+
+	// Log the start of the function
+	logger.Debug("Reading history", "type", objectType, "id", id, "from", from, "to", to)
+
+	// Validate the arguments
+	err := validate(ctx,
+		idArg(idName, id),
+		arg("from", from, rfc3339),
+		arg("to", to, rfc3339),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end, err := historyWindow(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := assetKey(ctx, objectType, id)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetHistoryForKey(key)
+	if err != nil {
+		return nil, internalError("failed to get history for %s %s: %v", objectType, id, err)
+	}
+	defer resultsIterator.Close()
+
+	// Read every version first, the changes of a version need the one before it
+	var versions []HistoryEntry
+	var values [][]byte
+	var times []time.Time
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, internalError("failed to read query results: %v", err)
+		}
+
+		entry := HistoryEntry{
+			TxID:      queryResponse.TxId,
+			Timestamp: historyTimestamp(queryResponse.Timestamp),
+			IsDelete:  queryResponse.IsDelete,
+		}
+		var value []byte
+		if !queryResponse.IsDelete {
+			value = queryResponse.Value
+			err = decode(value, &entry)
+			if err != nil {
+				return nil, internalError("failed to decode %s %s: %v", objectType, id, err)
+			}
+		}
+		versions = append(versions, entry)
+		values = append(values, value)
+		times = append(times, queryResponse.Timestamp.AsTime())
+	}
+	if len(versions) == 0 {
+		return nil, notFound(objectType, id)
+	}
+
+	// The audit trail records who wrote each version
+	auditEntries, err := readAuditEntries(ctx, objectType, id)
+	if err != nil {
+		return nil, err
+	}
+	writers := make(map[string]AuditEntry, len(auditEntries))
+	for _, auditEntry := range auditEntries {
+		writers[auditEntry.TxID] = auditEntry
+	}
+
+	history := []HistoryEntry{}
+	for i, entry := range versions {
+		if (!start.IsZero() && times[i].Before(start)) || (!end.IsZero() && times[i].After(end)) {
+			continue
+		}
+
+		// Versions are newest first, so the one before is the next one
+		var previous []byte
+		if i+1 < len(values) {
+			previous = values[i+1]
+		}
+		entry.Changes, err = diffStates(previous, values[i])
+		if err != nil {
+			return nil, internalError("failed to compare versions of %s %s: %v", objectType, id, err)
+		}
+
+		if writer, ok := writers[entry.TxID]; ok {
+			entry.Actor = writer.Actor
+			entry.MSPID = writer.MSPID
+			entry.Action = writer.Action
+		}
+		history = append(history, entry)
+	}
+
+	// Log the success of the operation
+	logger.Info("History read", "type", objectType, "id", id, "entries", len(history))
+
+	return history, nil
+}
+
+// historyWindow parses the optional bounds of a history window
+func historyWindow(from string, to string) (time.Time, time.Time, error) {
+	var start, end time.Time
+	var err error
+	if from != "" {
+		start, err = time.Parse(time.RFC3339, from)
+		if err != nil {
+			return start, end, invalidArgument("the start %q is not an RFC3339 timestamp", from)
+		}
+	}
+	if to != "" {
+		end, err = time.Parse(time.RFC3339, to)
+		if err != nil {
+			return start, end, invalidArgument("the end %q is not an RFC3339 timestamp", to)
+		}
+	}
+	if !start.IsZero() && !end.IsZero() && end.Before(start) {
+		return start, end, invalidArgument("the end %s is before the start %s", to, from)
+	}
+	return start, end, nil
+}
+
+// historyTimestamp formats the commit timestamp of a version as RFC3339 in UTC
+func historyTimestamp(timestamp *timestamppb.Timestamp) string {
+	if timestamp == nil {
+		return ""
+	}
+	return timestamp.AsTime().UTC().Format(time.RFC3339)
+}
+
+// diffStates returns the fields that differ between two JSON states of an asset, sorted
+// by name. A nil state has no fields
+func diffStates(previous []byte, current []byte) ([]FieldChange, error) {
+	before, err := flattenState(previous)
+	if err != nil {
+		return nil, err
+	}
+	after, err := flattenState(current)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]string, 0, len(after))
+	for field := range after {
+		fields = append(fields, field)
+	}
+	for field := range before {
+		if _, ok := after[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	changes := []FieldChange{}
+	for _, field := range fields {
+		if before[field] != after[field] {
+			changes = append(changes, FieldChange{Field: field, Previous: before[field], Current: after[field]})
+		}
+	}
+	return changes, nil
+}
+
+// flattenState returns the JSON encoded values of the fields of a state by path
+func flattenState(state []byte) (map[string]string, error) {
+	fields := map[string]string{}
+	if state == nil {
+		return fields, nil
+	}
+	value, err := decodeJSON(state)
+	if err != nil {
+		return nil, err
+	}
+	return fields, flattenValue("", value, fields)
+}
+
+// flattenValue adds a value to the fields, descending into non-empty objects and arrays
+func flattenValue(path string, value interface{}, fields map[string]string) error {
+	child := func(name string) string {
+		if path == "" {
+			return name
+		}
+		return path + "." + name
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) > 0 {
+			for name, item := range v {
+				if err := flattenValue(child(name), item, fields); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	case []interface{}:
+		if len(v) > 0 {
+			for i, item := range v {
+				if err := flattenValue(child(strconv.Itoa(i)), item, fields); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	fields[path] = string(encoded)
+	return nil
+}
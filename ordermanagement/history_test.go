@@ -0,0 +1,176 @@
+package ordermanagement
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func TestGetHistoryForKey(t *testing.T) {
+	env := newTestEnv(t)
+	env.createOrder("ORD-1")
+	env.mustSubmit(RoleSeller, "UpdateOrder", func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.UpdateOrder(ctx, "ORD-1", "2024-03-02", "12 pallets", "ACH")
+	})
+	env.mustSubmit(RoleSeller, "DeleteOrder", func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.DeleteOrder(ctx, "ORD-1")
+	})
+
+	var history []HistoryQueryResult
+	env.mustSubmit(RoleAuditor, "GetHistoryForKey", func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		history, err = env.contract.GetHistoryForKey(ctx, "ORD-1")
+		return err
+	})
+
+	if len(history) != 3 {
+		t.Fatalf("got %d history entries, want 3", len(history))
+	}
+	if !history[0].IsDelete || history[0].TxId != "tx3" {
+		t.Errorf("newest entry should be the delete of tx3: %+v", history[0])
+	}
+	if history[1].Order.OrderDetail != "12 pallets" || history[1].TxId != "tx2" {
+		t.Errorf("unexpected update entry: %+v", history[1])
+	}
+	if history[2].Order.OrderDetail != "10 pallets" || history[2].TxId != "tx1" {
+		t.Errorf("unexpected create entry: %+v", history[2])
+	}
+}
+
+// changeOf returns the change of the given field, or nil
+func changeOf(entry HistoryEntry, field string) *FieldChange {
+	for i := range entry.Changes {
+		if entry.Changes[i].Field == field {
+			return &entry.Changes[i]
+		}
+	}
+	return nil
+}
+
+func TestGetOrderHistory(t *testing.T) {
+	env := newTestEnv(t)
+	env.createOrder("ORD-1")
+	env.mustSubmit(RoleSeller, "UpdateOrder", func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.UpdateOrder(ctx, "ORD-1", "2024-03-01", "12 pallets", "CreditCard")
+	})
+	env.mustSubmit(RoleSeller, "DeleteOrder", func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.DeleteOrder(ctx, "ORD-1")
+	})
+
+	history := func(from string, to string) ([]HistoryEntry, error) {
+		var history []HistoryEntry
+		err := env.submit(RoleAuditor, "GetOrderHistory", func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			history, err = env.contract.GetOrderHistory(ctx, "ORD-1", from, to)
+			return err
+		})
+		return history, err
+	}
+
+	entries, err := history("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("got %d history entries, want 3", len(entries))
+	}
+	seller, _ := testIdentity(RoleSeller).GetID()
+	for i, want := range []struct{ txID, timestamp, action string }{
+		{"tx3", "2024-03-01T10:02:00Z", EventOrderDeleted},
+		{"tx2", "2024-03-01T10:01:00Z", EventOrderUpdated},
+		{"tx1", "2024-03-01T10:00:00Z", EventOrderCreated},
+	} {
+		entry := entries[i]
+		if entry.TxID != want.txID || entry.Timestamp != want.timestamp || entry.Action != want.action || entry.Actor != seller || entry.MSPID != "Org1MSP" {
+			t.Errorf("entry %d = %s at %s by %s, %s, want %+v", i, entry.TxID, entry.Timestamp, entry.Actor, entry.Action, want)
+		}
+	}
+
+	deleted, updated, created := entries[0], entries[1], entries[2]
+	if !deleted.IsDelete || deleted.Order != nil || changeOf(deleted, "orderNo") == nil || changeOf(deleted, "orderNo").Current != "" {
+		t.Errorf("unexpected delete entry: %+v", deleted)
+	}
+	wantChanges := map[string]FieldChange{
+		"orderDetail": {Field: "orderDetail", Previous: `"10 pallets"`, Current: `"12 pallets"`},
+		"updatedAt":   {Field: "updatedAt", Previous: `"2024-03-01T10:00:00Z"`, Current: `"2024-03-01T10:01:00Z"`},
+		"version":     {Field: "version", Previous: "1", Current: "2"},
+	}
+	if len(updated.Changes) != len(wantChanges) || updated.Order == nil || updated.Order.OrderDetail != "12 pallets" {
+		t.Errorf("unexpected update entry: %+v", updated)
+	}
+	for field, want := range wantChanges {
+		if got := changeOf(updated, field); got == nil || *got != want {
+			t.Errorf("change of %s = %v, want %v", field, got, want)
+		}
+	}
+	if change := changeOf(created, "orderNo"); change == nil || change.Previous != "" || change.Current != `"ORD-1"` {
+		t.Errorf("unexpected create entry: %+v", created)
+	}
+
+	// The window keeps the update, still compared with the version before it
+	entries, err = history("2024-03-01T10:01:00Z", "2024-03-01T10:01:30Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].TxID != "tx2" || changeOf(entries[0], "orderDetail") == nil {
+		t.Errorf("windowed history = %+v, want the update of tx2", entries)
+	}
+
+	if _, err := history("2024-03-02T00:00:00Z", "2024-03-01T00:00:00Z"); errorCode(err) != CodeInvalidArgument {
+		t.Errorf("reversed window: got %v", err)
+	}
+	_, err = history("yesterday", "")
+	if got := fieldRules(validationErrors(t, err)); got["from"] != "timestamp" {
+		t.Errorf("rejected fields = %v, want a timestamp error on from", got)
+	}
+	err = env.submit(RoleAuditor, "GetOrderHistory", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.contract.GetOrderHistory(ctx, "ORD-2", "", "")
+		return err
+	})
+	if errorCode(err) != CodeNotFound {
+		t.Errorf("history of a missing order: got %v", err)
+	}
+}
+
+func TestGetPaymentAndShipmentHistory(t *testing.T) {
+	env := newTestEnv(t)
+	env.createOrder("ORD-1")
+	env.createPayment("PAY-1", "ORD-1")
+	env.mustSubmit(RoleBank, "AuthorizePayment", func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.AuthorizePayment(ctx, "PAY-1")
+	})
+	env.createShipment("SHP-1", "ORD-1")
+	if err := env.addTrackingEvent("SHP-1", "IT", "Rotterdam", "2024-03-02T08:00:00Z"); err != nil {
+		t.Fatal(err)
+	}
+
+	var payments, shipments []HistoryEntry
+	env.mustSubmit(RoleAuditor, "GetPaymentHistory", func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		payments, err = env.contract.GetPaymentHistory(ctx, "PAY-1", "", "")
+		return err
+	})
+	env.mustSubmit(RoleAuditor, "GetShipmentHistory", func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		shipments, err = env.contract.GetShipmentHistory(ctx, "SHP-1", "", "")
+		return err
+	})
+
+	bank, _ := testIdentity(RoleBank).GetID()
+	if len(payments) != 2 || payments[0].Payment == nil || payments[0].Actor != bank || payments[0].Action != EventPaymentStatusChanged {
+		t.Fatalf("unexpected payment history: %+v", payments)
+	}
+	if change := changeOf(payments[0], "status"); change == nil || change.Previous != `"Pending"` || change.Current != `"Authorized"` {
+		t.Errorf("change of status = %v", change)
+	}
+	if change := changeOf(payments[1], "amount.amount"); change == nil || change.Current != "10000" {
+		t.Errorf("change of amount.amount = %v", change)
+	}
+
+	if len(shipments) != 2 || shipments[0].Shipment == nil || shipments[0].Action != EventTrackingEventAdded {
+		t.Fatalf("unexpected shipment history: %+v", shipments)
+	}
+	if change := changeOf(shipments[0], "trackingEvents"); change == nil || change.Previous != "" || change.Current != "1" {
+		t.Errorf("change of trackingEvents = %v", change)
+	}
+}
@@ -0,0 +1,210 @@
+package ordermanagement
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Object types used as the composite key namespace of each asset type, so that
+// orders, payments and shipments sharing the same ID never collide
+const (
+	orderObjectType    = "order"
+	paymentObjectType  = "payment"
+	shipmentObjectType = "shipment"
+)
+
+// assetKey returns the world state key of the asset with the given ID in the given namespace
+func assetKey(ctx contractapi.TransactionContextInterface, objectType string, id string) (string, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(objectType, []string{id})
+	if err != nil {
+		return "", invalidArgument("failed to create %s key for %s: %v", objectType, id, err)
+	}
+	return key, nil
+}
+
+// assetID returns the ID part of a composite asset key
+func assetID(ctx contractapi.TransactionContextInterface, key string) (string, error) {
+	_, attributes, err := ctx.GetStub().SplitCompositeKey(key)
+	if err != nil {
+		return "", internalError("failed to split key %s: %v", key, err)
+	}
+	if len(attributes) == 0 {
+		return "", internalError("the key %s has no attributes", key)
+	}
+	return attributes[0], nil
+}
+
+// actionAssetMigrated is the audit action recorded for an asset moved to its composite key
+const actionAssetMigrated = "AssetMigrated"
+
+// orderIndexes are the reverse indexes listing the records of each type under their order
+var orderIndexes = map[string]string{
+	paymentObjectType:  orderPaymentIndex,
+	shipmentObjectType: orderShipmentIndex,
+}
+
+// MigrationReport structure used for handling result of the key migration
+type MigrationReport struct {
+	Orders    int      `json:"orders"`
+	Payments  int      `json:"payments"`
+	Shipments int      `json:"shipments"`
+	Skipped   []string `json:"skipped,omitempty" metadata:",optional"`
+}
+
+// flatEntry is a world state entry stored under a plain, non-composite key
+type flatEntry struct {
+	key        string
+	objectType string
+	value      []byte
+}
+
+// MigrateToCompositeKeys re-keys the assets written under their raw IDs into their
+// composite key namespaces. Entries whose type cannot be determined, or whose
+// namespaced key is already taken, are left in place and reported as skipped. Every
+// migrated asset starts its audit trail with an AssetMigrated entry, and payments and
+// shipments that reference an order are indexed under it like those created since
+func (s *SmartContract) MigrateToCompositeKeys(ctx contractapi.TransactionContextInterface) (*MigrationReport, error) {
+	logger := newLogger(ctx)
+
+	// Log the start of the function
+	logger.Debug("Migrating flat keys to composite keys")
+
+	// Range queries over simple keys never return composite keys, so only the
+	// entries written before the migration are visited
+	resultsIterator, err := ctx.GetStub().GetStateByRange("", "")
+	if err != nil {
+		return nil, internalError("failed to read world state: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	report := &MigrationReport{}
+	var entries []flatEntry
+
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, internalError("failed to read query results: %v", err)
+		}
+
+		objectType := detectObjectType(queryResponse.Value)
+		if objectType == "" {
+			report.Skipped = append(report.Skipped, queryResponse.Key)
+			continue
+		}
+		entries = append(entries, flatEntry{key: queryResponse.Key, objectType: objectType, value: queryResponse.Value})
+	}
+
+	for _, entry := range entries {
+		key, err := assetKey(ctx, entry.objectType, entry.key)
+		if err != nil {
+			return nil, err
+		}
+
+		existing, err := ctx.GetStub().GetState(key)
+		if err != nil {
+			return nil, internalError("failed to read %s %s from world state: %v", entry.objectType, entry.key, err)
+		}
+		if existing != nil {
+			report.Skipped = append(report.Skipped, entry.key)
+			continue
+		}
+
+		// Orders are tagged with their document type so that rich queries find them
+		value := entry.value
+		if entry.objectType == orderObjectType {
+			value, err = withDocType(entry.value, orderObjectType)
+			if err != nil {
+				return nil, internalError("failed to tag order %s with its document type: %v", entry.key, err)
+			}
+		}
+
+		err = ctx.GetStub().PutState(key, value)
+		if err != nil {
+			return nil, internalError("failed to put %s %s to world state: %v", entry.objectType, entry.key, err)
+		}
+		err = ctx.GetStub().DelState(entry.key)
+		if err != nil {
+			return nil, internalError("failed to delete flat key %s from world state: %v", entry.key, err)
+		}
+
+		// Link the record to its order so that GetOrderPayments, GetOrderShipments and
+		// checkRetention see it. The order may be migrated in the same transaction, so
+		// it is not read back
+		if index, ok := orderIndexes[entry.objectType]; ok {
+			if orderNo := referencedOrderNo(value); orderNo != "" {
+				err = putLink(ctx, index, orderNo, entry.key)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		err = appendAudit(ctx, actionAssetMigrated, entry.objectType, entry.key, value)
+		if err != nil {
+			return nil, err
+		}
+
+		switch entry.objectType {
+		case orderObjectType:
+			report.Orders++
+		case paymentObjectType:
+			report.Payments++
+		case shipmentObjectType:
+			report.Shipments++
+		}
+	}
+
+	// Log the success of the operation
+	logger.Info("Flat keys migrated", "orders", report.Orders, "payments", report.Payments, "shipments", report.Shipments, "skipped", len(report.Skipped))
+
+	return report, nil
+}
+
+// detectObjectType infers the asset type of a flat world state entry from its JSON fields
+func detectObjectType(value []byte) string {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(value, &fields); err != nil {
+		return ""
+	}
+
+	// Payments and shipments may reference an order, so they are recognised first
+	if _, ok := fields["shipmentId"]; ok {
+		return shipmentObjectType
+	}
+	if _, ok := fields["amount"]; ok {
+		return paymentObjectType
+	}
+	if _, ok := fields["orderNo"]; ok {
+		return orderObjectType
+	}
+	return ""
+}
+
+// referencedOrderNo returns the order number a flat payment or shipment refers to, or
+// an empty string when it refers to none
+func referencedOrderNo(value []byte) string {
+	var record struct {
+		OrderNo string `json:"orderNo"`
+	}
+	if err := json.Unmarshal(value, &record); err != nil {
+		return ""
+	}
+	return record.OrderNo
+}
+
+// withDocType sets the docType field of a JSON document
+func withDocType(value []byte, docType string) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(value, &fields); err != nil {
+		return nil, err
+	}
+
+	docTypeJSON, err := json.Marshal(docType)
+	if err != nil {
+		return nil, err
+	}
+	fields["docType"] = docTypeJSON
+
+	return json.Marshal(fields)
+}
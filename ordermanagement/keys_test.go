@@ -0,0 +1,87 @@
+package ordermanagement
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func TestMigrateToCompositeKeys(t *testing.T) {
+	env := newTestEnv(t)
+	env.createOrder("ORD-2")
+
+	env.stub.PutCommittedState("ORD-1", []byte(`{"orderNo":"ORD-1","invoice":"INV-1"}`))
+	env.stub.PutCommittedState("ORD-2", []byte(`{"orderNo":"ORD-2","invoice":"INV-OLD"}`))
+	env.stub.PutCommittedState("PAY-1", []byte(`{"id":"PAY-1","amount":10.5}`))
+	env.stub.PutCommittedState("SHIP-1", []byte(`{"id":"SHIP-1","shipmentId":"S-1"}`))
+	env.stub.PutCommittedState("unknown", []byte(`{"foo":"bar"}`))
+
+	var report *MigrationReport
+	env.mustSubmit(RoleAdmin, "MigrateToCompositeKeys", func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		report, err = env.contract.MigrateToCompositeKeys(ctx)
+		return err
+	})
+
+	want := &MigrationReport{Orders: 1, Payments: 1, Shipments: 1, Skipped: []string{"unknown", "ORD-2"}}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("got report %+v, want %+v", report, want)
+	}
+
+	if order := env.readOrder("ORD-1"); order.Invoice != "INV-1" {
+		t.Errorf("migrated order has invoice %s, want INV-1", order.Invoice)
+	}
+	if order := env.readOrder("ORD-2"); order.Invoice != "" {
+		t.Errorf("an existing order was overwritten with invoice %s", order.Invoice)
+	}
+	for _, key := range []string{"ORD-1", "PAY-1", "SHIP-1"} {
+		if env.stub.CommittedState(key) != nil {
+			t.Errorf("flat key %s was not removed", key)
+		}
+	}
+	for _, key := range []string{"ORD-2", "unknown"} {
+		if env.stub.CommittedState(key) == nil {
+			t.Errorf("skipped key %s was removed", key)
+		}
+	}
+
+	for _, asset := range []string{"order:ORD-1", "payment:PAY-1", "shipment:SHIP-1"} {
+		trail := env.auditTrail(asset)
+		if len(trail) != 1 || trail[0].Action != actionAssetMigrated {
+			t.Errorf("audit trail of %s = %+v, want a single migration entry", asset, trail)
+		}
+	}
+	if trail := env.auditTrail("order:ORD-2"); len(trail) != 1 || trail[0].Action != EventOrderCreated {
+		t.Errorf("the skipped order was audited as migrated: %+v", trail)
+	}
+}
+
+func TestMigratedRecordsAreLinked(t *testing.T) {
+	env := newTestEnv(t)
+	env.stub.PutCommittedState("ORD-1", []byte(`{"orderNo":"ORD-1","invoice":"INV-1"}`))
+	env.stub.PutCommittedState("PAY-1", []byte(`{"id":"PAY-1","orderNo":"ORD-1","amount":10.5}`))
+	env.stub.PutCommittedState("PAY-2", []byte(`{"id":"PAY-2","amount":20}`))
+	env.stub.PutCommittedState("SHIP-1", []byte(`{"id":"SHIP-1","orderNo":"ORD-1","shipmentId":"S-1"}`))
+	env.mustSubmit(RoleAdmin, "MigrateToCompositeKeys", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.contract.MigrateToCompositeKeys(ctx)
+		return err
+	})
+
+	var dossier *OrderDossier
+	env.mustSubmit(RoleAuditor, "GetOrderDossier", func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		dossier, err = env.contract.GetOrderDossier(ctx, "ORD-1")
+		return err
+	})
+	if len(dossier.Payments) != 1 || dossier.Payments[0].ID != "PAY-1" || len(dossier.Shipments) != 1 || dossier.Shipments[0].ID != "SHIP-1" {
+		t.Errorf("migrated records are not linked to their order: %+v", dossier)
+	}
+
+	err := env.submit(RoleSeller, "DeleteOrder", func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.DeleteOrder(ctx, "ORD-1")
+	})
+	if errorCode(err) != CodeConflict {
+		t.Errorf("an order with migrated payments was deleted: %v", err)
+	}
+}
@@ -0,0 +1,253 @@
+package ordermanagement
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// OrderStatus represents a step of the order lifecycle
+type OrderStatus string
+
+const (
+	StatusCreated   OrderStatus = "Created"
+	StatusConfirmed OrderStatus = "Confirmed"
+	StatusPacking   OrderStatus = "Packing"
+	StatusPacked    OrderStatus = "Packed"
+	StatusShipped   OrderStatus = "Shipped"
+	StatusInTransit OrderStatus = "InTransit"
+	StatusDelivered OrderStatus = "Delivered"
+	StatusCancelled OrderStatus = "Cancelled"
+	StatusReturned  OrderStatus = "Returned"
+	StatusArchived  OrderStatus = "Archived"
+	StatusVoided    OrderStatus = "Voided"
+)
+
+// orderTransitions lists, for each status, the statuses an order may move to next.
+// Archived and voided orders leave the lifecycle until restored, see ArchiveOrder
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	StatusCreated:   {StatusConfirmed, StatusCancelled},
+	StatusConfirmed: {StatusPacking, StatusCancelled},
+	StatusPacking:   {StatusPacked, StatusCancelled},
+	StatusPacked:    {StatusShipped, StatusCancelled},
+	StatusShipped:   {StatusInTransit, StatusDelivered, StatusReturned},
+	StatusInTransit: {StatusDelivered, StatusReturned},
+	StatusDelivered: {StatusReturned},
+	StatusCancelled: {},
+	StatusReturned:  {},
+	StatusArchived:  {},
+	StatusVoided:    {},
+}
+
+// StatusTransition records a single lifecycle step of an order and who performed it
+type StatusTransition struct {
+	From      OrderStatus `json:"from"`
+	To        OrderStatus `json:"to"`
+	Actor     string      `json:"actor"`
+	MSPID     string      `json:"mspId"`
+	TxID      string      `json:"txId"`
+	Reason    string      `json:"reason,omitempty" metadata:",optional"`
+	Timestamp string      `json:"timestamp"`
+}
+
+// canTransition reports whether an order may move from one status to another
+func canTransition(from, to OrderStatus) bool {
+	for _, next := range orderTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// isFinal reports whether no further lifecycle step is allowed from the status
+func isFinal(status OrderStatus) bool {
+	return status == StatusDelivered || status == StatusCancelled || status == StatusReturned || isArchived(status)
+}
+
+// isArchived reports whether the status is one of the soft deleted statuses
+func isArchived(status OrderStatus) bool {
+	return status == StatusArchived || status == StatusVoided
+}
+
+// legacyPackingStatuses maps the free text packing statuses of the orders written before
+// the lifecycle, in lower case, to the status they stand for
+var legacyPackingStatuses = map[string]OrderStatus{
+	"pending":   StatusCreated,
+	"packing":   StatusPacking,
+	"packed":    StatusPacked,
+	"cancelled": StatusCancelled,
+}
+
+// lifecycleStatus returns the current status of the order. Orders written before the
+// lifecycle was introduced have no status. Their order track is used when it holds a
+// known status, such as Shipped, then their packing status, and they are treated as
+// Created when neither does, e.g. for an order track of "In Progress" and no packing status
+func (o *Order) lifecycleStatus() OrderStatus {
+	if o.Status != "" {
+		return o.Status
+	}
+	if _, ok := orderTransitions[OrderStatus(o.OrderTrack)]; ok {
+		return OrderStatus(o.OrderTrack)
+	}
+	if status, ok := legacyPackingStatuses[strings.ToLower(strings.TrimSpace(o.PackingStatus))]; ok {
+		return status
+	}
+	return StatusCreated
+}
+
+// setStatus moves the order to the given status and keeps the descriptive packing
+// status and order track fields in line with it
+func (o *Order) setStatus(status OrderStatus) {
+	o.Status = status
+	o.OrderTrack = string(status)
+
+	switch status {
+	case StatusCreated, StatusConfirmed:
+		o.PackingStatus = "Pending"
+	case StatusPacking:
+		o.PackingStatus = "Packing"
+	case StatusCancelled:
+		o.PackingStatus = "Cancelled"
+	case StatusArchived, StatusVoided:
+		// Soft deletion keeps the packing status the order had
+	default:
+		o.PackingStatus = "Packed"
+	}
+}
+
+// ConfirmOrder moves a created order to Confirmed
+func (s *SmartContract) ConfirmOrder(ctx contractapi.TransactionContextInterface, orderNo string) error {
+	return s.transitionOrder(ctx, orderNo, StatusConfirmed, "")
+}
+
+// StartPacking moves a confirmed order to Packing
+func (s *SmartContract) StartPacking(ctx contractapi.TransactionContextInterface, orderNo string) error {
+	return s.transitionOrder(ctx, orderNo, StatusPacking, "")
+}
+
+// MarkPacked moves an order being packed to Packed
+func (s *SmartContract) MarkPacked(ctx contractapi.TransactionContextInterface, orderNo string) error {
+	return s.transitionOrder(ctx, orderNo, StatusPacked, "")
+}
+
+// MarkShipped moves a packed order to Shipped
+func (s *SmartContract) MarkShipped(ctx contractapi.TransactionContextInterface, orderNo string) error {
+	return s.transitionOrder(ctx, orderNo, StatusShipped, "")
+}
+
+// MarkInTransit moves a shipped order to InTransit
+func (s *SmartContract) MarkInTransit(ctx contractapi.TransactionContextInterface, orderNo string) error {
+	return s.transitionOrder(ctx, orderNo, StatusInTransit, "")
+}
+
+// MarkDelivered moves a shipped or in transit order to Delivered
+func (s *SmartContract) MarkDelivered(ctx contractapi.TransactionContextInterface, orderNo string) error {
+	return s.transitionOrder(ctx, orderNo, StatusDelivered, "")
+}
+
+// CancelOrder cancels an order that has not been shipped yet
+func (s *SmartContract) CancelOrder(ctx contractapi.TransactionContextInterface, orderNo string, reason string) error {
+	return s.transitionOrder(ctx, orderNo, StatusCancelled, reason)
+}
+
+// ReturnOrder records the return of a shipped or delivered order
+func (s *SmartContract) ReturnOrder(ctx contractapi.TransactionContextInterface, orderNo string, reason string) error {
+	return s.transitionOrder(ctx, orderNo, StatusReturned, reason)
+}
+
+// transitionOrder moves an order to the given status if the lifecycle allows it and
+// records the step together with the identity of the caller
+func (s *SmartContract) transitionOrder(ctx contractapi.TransactionContextInterface, orderNo string, to OrderStatus, reason string) error {
+	return s.moveOrder(ctx, orderNo, textArg("reason", reason), EventOrderStatusChanged, func(order *Order, from OrderStatus) (OrderStatus, error) {
+		if !canTransition(from, to) {
+			return "", invalidTransition(orderObjectType, orderNo, string(from), string(to))
+		}
+		return to, nil
+	})
+}
+
+// moveOrder moves an order to the status returned by next, which rejects the step with
+// an error, and records the step together with the reason and the identity of the caller
+func (s *SmartContract) moveOrder(ctx contractapi.TransactionContextInterface, orderNo string, reason field, event string, next func(order *Order, from OrderStatus) (OrderStatus, error)) error {
+	// Record the transaction timestamp
+	timestamp, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	logger := newLogger(ctx)
+
+	// Log the start of the function
+	logger.Debug("Moving order", "orderNo", orderNo, "reason", reason.value)
+
+	// Validate the arguments
+	err = validate(ctx,
+		idArg("orderNo", orderNo),
+		reason,
+	)
+	if err != nil {
+		return err
+	}
+
+	// Retrieve transaction ID and caller ID
+	txID := ctx.GetStub().GetTxID()
+	callerID, _ := ctx.GetClientIdentity().GetID()
+	mspID, _ := ctx.GetClientIdentity().GetMSPID()
+
+	order, err := s.ReadOrder(ctx, orderNo)
+	if err != nil {
+		return err
+	}
+
+	from := order.lifecycleStatus()
+	to, err := next(order, from)
+	if err != nil {
+		return err
+	}
+
+	order.setStatus(to)
+	order.DocType = orderObjectType
+	order.UpdatedAt = timestamp
+	order.Version++
+	order.Transitions = append(order.Transitions, StatusTransition{
+		From:      from,
+		To:        to,
+		Actor:     callerID,
+		MSPID:     mspID,
+		TxID:      txID,
+		Reason:    reason.value,
+		Timestamp: timestamp,
+	})
+
+	orderJSON, err := json.Marshal(order)
+	if err != nil {
+		return err
+	}
+
+	key, err := assetKey(ctx, orderObjectType, orderNo)
+	if err != nil {
+		return err
+	}
+
+	previousJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return internalError("failed to read order %s from world state: %v", orderNo, err)
+	}
+
+	err = ctx.GetStub().PutState(key, orderJSON)
+	if err != nil {
+		return internalError("failed to update order %s in world state: %v", orderNo, err)
+	}
+
+	// Publish order event
+	err = recordMutation(ctx, event, orderObjectType, orderNo, previousJSON, orderJSON)
+	if err != nil {
+		return err
+	}
+
+	// Log the success of the operation
+	logger.Info("Order moved", "orderNo", orderNo, "from", from, "to", to)
+
+	return nil
+}
@@ -0,0 +1,183 @@
+package ordermanagement
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// lifecycleStep is a transition function together with the function name and role used to call it
+type lifecycleStep struct {
+	function string
+	role     string
+	call     func(s *SmartContract, ctx contractapi.TransactionContextInterface, orderNo string) error
+}
+
+var (
+	confirm = lifecycleStep{"ConfirmOrder", RoleSeller, func(s *SmartContract, ctx contractapi.TransactionContextInterface, orderNo string) error {
+		return s.ConfirmOrder(ctx, orderNo)
+	}}
+	startPacking = lifecycleStep{"StartPacking", RoleSeller, func(s *SmartContract, ctx contractapi.TransactionContextInterface, orderNo string) error {
+		return s.StartPacking(ctx, orderNo)
+	}}
+	markPacked = lifecycleStep{"MarkPacked", RoleSeller, func(s *SmartContract, ctx contractapi.TransactionContextInterface, orderNo string) error {
+		return s.MarkPacked(ctx, orderNo)
+	}}
+	markShipped = lifecycleStep{"MarkShipped", RoleCarrier, func(s *SmartContract, ctx contractapi.TransactionContextInterface, orderNo string) error {
+		return s.MarkShipped(ctx, orderNo)
+	}}
+	markInTransit = lifecycleStep{"MarkInTransit", RoleCarrier, func(s *SmartContract, ctx contractapi.TransactionContextInterface, orderNo string) error {
+		return s.MarkInTransit(ctx, orderNo)
+	}}
+	markDelivered = lifecycleStep{"MarkDelivered", RoleCarrier, func(s *SmartContract, ctx contractapi.TransactionContextInterface, orderNo string) error {
+		return s.MarkDelivered(ctx, orderNo)
+	}}
+	cancel = lifecycleStep{"CancelOrder", RoleBuyer, func(s *SmartContract, ctx contractapi.TransactionContextInterface, orderNo string) error {
+		return s.CancelOrder(ctx, orderNo, "changed my mind")
+	}}
+	returnOrder = lifecycleStep{"ReturnOrder", RoleBuyer, func(s *SmartContract, ctx contractapi.TransactionContextInterface, orderNo string) error {
+		return s.ReturnOrder(ctx, orderNo, "damaged")
+	}}
+)
+
+// run applies the step to an order as a transaction
+func (step lifecycleStep) run(env *testEnv, orderNo string) error {
+	return env.submit(step.role, step.function, func(ctx contractapi.TransactionContextInterface) error {
+		return step.call(env.contract, ctx, orderNo)
+	})
+}
+
+func TestOrderLifecycle(t *testing.T) {
+	env := newTestEnv(t)
+	env.createOrder("ORD-1")
+
+	steps := []struct {
+		step          lifecycleStep
+		status        OrderStatus
+		packingStatus string
+	}{
+		{confirm, StatusConfirmed, "Pending"},
+		{startPacking, StatusPacking, "Packing"},
+		{markPacked, StatusPacked, "Packed"},
+		{markShipped, StatusShipped, "Packed"},
+		{markInTransit, StatusInTransit, "Packed"},
+		{markDelivered, StatusDelivered, "Packed"},
+		{returnOrder, StatusReturned, "Packed"},
+	}
+
+	for i, s := range steps {
+		if err := s.step.run(env, "ORD-1"); err != nil {
+			t.Fatalf("%s failed: %v", s.step.function, err)
+		}
+
+		order := env.readOrder("ORD-1")
+		if order.Status != s.status || order.OrderTrack != string(s.status) || order.PackingStatus != s.packingStatus {
+			t.Errorf("after %s got status %s/%s/%s", s.step.function, order.Status, order.OrderTrack, order.PackingStatus)
+		}
+		if len(order.Transitions) != i+1 {
+			t.Fatalf("after %s got %d transitions, want %d", s.step.function, len(order.Transitions), i+1)
+		}
+
+		transition := order.Transitions[i]
+		if transition.To != s.status || transition.Actor != testIdentity(s.step.role).ID || transition.MSPID != testMSPID(s.step.role) || transition.TxID == "" || transition.Timestamp == "" {
+			t.Errorf("unexpected transition recorded by %s: %+v", s.step.function, transition)
+		}
+		if event := env.lastEvent(); event.Name != EventOrderStatusChanged {
+			t.Errorf("event = %s, want %s", event.Name, EventOrderStatusChanged)
+		}
+	}
+
+	if reason := env.readOrder("ORD-1").Transitions[6].Reason; reason != "damaged" {
+		t.Errorf("return reason = %q, want damaged", reason)
+	}
+}
+
+func TestIllegalTransitions(t *testing.T) {
+	tests := []struct {
+		name    string
+		history []lifecycleStep
+		step    lifecycleStep
+	}{
+		{name: "pack before confirmation", step: markPacked},
+		{name: "ship before packing", history: []lifecycleStep{confirm}, step: markShipped},
+		{name: "deliver before shipping", history: []lifecycleStep{confirm, startPacking, markPacked}, step: markDelivered},
+		{name: "back to packing after shipping", history: []lifecycleStep{confirm, startPacking, markPacked, markShipped}, step: startPacking},
+		{name: "cancel after shipping", history: []lifecycleStep{confirm, startPacking, markPacked, markShipped}, step: cancel},
+		{name: "confirm a cancelled order", history: []lifecycleStep{cancel}, step: confirm},
+		{name: "return before shipping", history: []lifecycleStep{confirm}, step: returnOrder},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			env.createOrder("ORD-1")
+			for _, step := range tt.history {
+				if err := step.run(env, "ORD-1"); err != nil {
+					t.Fatalf("%s failed: %v", step.function, err)
+				}
+			}
+			before := env.readOrder("ORD-1").Status
+
+			err := tt.step.run(env, "ORD-1")
+			if err == nil || !strings.Contains(err.Error(), "cannot move from") {
+				t.Fatalf("got error %v, want an illegal transition error", err)
+			}
+			if after := env.readOrder("ORD-1").Status; after != before {
+				t.Errorf("status changed from %s to %s on a rejected transition", before, after)
+			}
+		})
+	}
+}
+
+func TestTransitionMissingOrder(t *testing.T) {
+	env := newTestEnv(t)
+	if err := confirm.run(env, "ORD-1"); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("got error %v, want a missing order error", err)
+	}
+}
+
+func TestLegacyOrderStatus(t *testing.T) {
+	tests := []struct {
+		order Order
+		want  OrderStatus
+	}{
+		{Order{Status: StatusPacked}, StatusPacked},
+		{Order{OrderTrack: "Shipped"}, StatusShipped},
+		{Order{OrderTrack: "In Progress"}, StatusCreated},
+		{Order{OrderTrack: "In Progress", PackingStatus: "Packing"}, StatusPacking},
+		{Order{OrderTrack: "In Progress", PackingStatus: "packed"}, StatusPacked},
+		{Order{OrderTrack: "Shipped", PackingStatus: "Packing"}, StatusShipped},
+		{Order{PackingStatus: "Pending"}, StatusCreated},
+		{Order{PackingStatus: "Unknown"}, StatusCreated},
+		{Order{}, StatusCreated},
+	}
+
+	for _, tt := range tests {
+		if got := tt.order.lifecycleStatus(); got != tt.want {
+			t.Errorf("lifecycleStatus of %+v = %s, want %s", tt.order, got, tt.want)
+		}
+	}
+}
+
+func TestLegacyPackingOrder(t *testing.T) {
+	env := newTestEnv(t)
+	env.stub.PutCommittedState(orderKey(t, env, "logis_ordr_1"), []byte(`{"docType":"order","orderNo":"logis_ordr_1","date":"2024-03-01",`+
+		`"orderDetail":"Sample order details 1","invoice":"INV-001","packingStatus":"Packing","paymentMethod":"Credit Card","orderTrack":"In Progress"}`))
+
+	// The order is being packed, so it cannot be confirmed or packed again
+	if err := confirm.run(env, "logis_ordr_1"); errorCode(err) != CodeInvalidStateTransition {
+		t.Errorf("confirming a packing order: got %v", err)
+	}
+	if err := startPacking.run(env, "logis_ordr_1"); errorCode(err) != CodeInvalidStateTransition {
+		t.Errorf("packing a packing order: got %v", err)
+	}
+
+	if err := markPacked.run(env, "logis_ordr_1"); err != nil {
+		t.Fatal(err)
+	}
+	order := env.readOrder("logis_ordr_1")
+	if order.Status != StatusPacked || order.Transitions[0].From != StatusPacking {
+		t.Errorf("unexpected order after packing: status %s, transitions %+v", order.Status, order.Transitions)
+	}
+}
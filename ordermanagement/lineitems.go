@@ -0,0 +1,315 @@
+package ordermanagement
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// LineItem is a single ordered article. Subtotal, Tax and Total are computed by the
+// chaincode in the currency of the unit price, when they are supplied by the caller
+// they must match the computed values. TaxRate is a percentage, 20 meaning 20%
+type LineItem struct {
+	LineNo      int     `json:"lineNo,omitempty" metadata:",optional"`
+	SKU         string  `json:"sku"`
+	Description string  `json:"description"`
+	Quantity    float64 `json:"quantity"`
+	Unit        string  `json:"unit"`
+	UnitPrice   Money   `json:"unitPrice"`
+	TaxRate     float64 `json:"taxRate"`
+	Subtotal    *Money  `json:"subtotal,omitempty" metadata:",optional"`
+	Tax         *Money  `json:"tax,omitempty" metadata:",optional"`
+	Total       *Money  `json:"total,omitempty" metadata:",optional"`
+}
+
+// price validates a line item and computes its subtotal, tax and total. Subtotal and
+// tax are each rounded to the minor unit of the currency
+func (item *LineItem) price() error {
+	if item.SKU == "" {
+		return fmt.Errorf("the SKU must not be empty")
+	}
+	if item.Quantity <= 0 {
+		return fmt.Errorf("the quantity of %s must be positive, got %v", item.SKU, item.Quantity)
+	}
+	if item.Unit == "" {
+		return fmt.Errorf("the unit of %s must not be empty", item.SKU)
+	}
+	if item.UnitPrice.Amount < 0 {
+		return fmt.Errorf("the unit price of %s must not be negative, got %s", item.SKU, item.UnitPrice)
+	}
+	if item.TaxRate < 0 || item.TaxRate > 100 {
+		return fmt.Errorf("the tax rate of %s must be between 0 and 100, got %v", item.SKU, item.TaxRate)
+	}
+	if !currencyPattern.MatchString(item.UnitPrice.Currency) {
+		return fmt.Errorf("the currency of %s must be an ISO 4217 code, got %q", item.SKU, item.UnitPrice.Currency)
+	}
+
+	quantity, err := exactDecimal(item.Quantity)
+	if err != nil {
+		return fmt.Errorf("invalid quantity of %s: %v", item.SKU, err)
+	}
+	rate, err := exactDecimal(item.TaxRate)
+	if err != nil {
+		return fmt.Errorf("invalid tax rate of %s: %v", item.SKU, err)
+	}
+
+	subtotal, err := item.UnitPrice.Mul(quantity)
+	if err != nil {
+		return err
+	}
+	tax, err := subtotal.Mul(rate.Quo(rate, big.NewRat(100, 1)))
+	if err != nil {
+		return err
+	}
+	total, err := subtotal.Add(tax)
+	if err != nil {
+		return err
+	}
+
+	if item.Subtotal != nil && *item.Subtotal != subtotal {
+		return fmt.Errorf("the subtotal of %s is %s, not %s", item.SKU, subtotal, item.Subtotal)
+	}
+	if item.Tax != nil && *item.Tax != tax {
+		return fmt.Errorf("the tax of %s is %s, not %s", item.SKU, tax, item.Tax)
+	}
+	if item.Total != nil && *item.Total != total {
+		return fmt.Errorf("the total of %s is %s, not %s", item.SKU, total, item.Total)
+	}
+
+	item.Subtotal = &subtotal
+	item.Tax = &tax
+	item.Total = &total
+	return nil
+}
+
+// setLineItems validates and prices the line items of the order, numbers new items
+// after the existing ones and recomputes the order totals. All items of an order
+// must share one currency
+func (o *Order) setLineItems(items []LineItem) error {
+	nextLineNo := 1
+	for _, item := range o.LineItems {
+		if item.LineNo >= nextLineNo {
+			nextLineNo = item.LineNo + 1
+		}
+	}
+
+	o.Subtotal = nil
+	o.Tax = nil
+	o.Total = nil
+
+	var subtotal, tax, total Money
+	seen := map[int]bool{}
+	for i := range items {
+		item := &items[i]
+		if err := item.price(); err != nil {
+			return err
+		}
+
+		if item.LineNo == 0 {
+			item.LineNo = nextLineNo
+			nextLineNo++
+		}
+		if seen[item.LineNo] {
+			return fmt.Errorf("the line number %d is used twice", item.LineNo)
+		}
+		seen[item.LineNo] = true
+
+		if i == 0 {
+			subtotal, tax, total = *item.Subtotal, *item.Tax, *item.Total
+			continue
+		}
+		if item.UnitPrice.Currency != subtotal.Currency {
+			return fmt.Errorf("all line items must be in %s, %s is in %s", subtotal.Currency, item.SKU, item.UnitPrice.Currency)
+		}
+
+		var err error
+		if subtotal, err = subtotal.Add(*item.Subtotal); err != nil {
+			return err
+		}
+		if tax, err = tax.Add(*item.Tax); err != nil {
+			return err
+		}
+		if total, err = total.Add(*item.Total); err != nil {
+			return err
+		}
+	}
+
+	if len(items) > 0 {
+		o.Subtotal, o.Tax, o.Total = &subtotal, &tax, &total
+	}
+	o.LineItems = items
+	return nil
+}
+
+// newLineItems numbers the private line items given to CreateOrder or UpdateOrder from 1,
+// ignoring any line number supplied by the caller
+func newLineItems(items []LineItem) []LineItem {
+	numbered := make([]LineItem, len(items))
+	for i, item := range items {
+		item.LineNo = i + 1
+		numbered[i] = item
+	}
+	return numbered
+}
+
+// AddLineItem appends a line item to the private line items of an order and recomputes
+// its totals. The line item is passed in the transient map under "private"
+func (s *SmartContract) AddLineItem(ctx contractapi.TransactionContextInterface, orderNo string) error {
+	return s.changeLineItems(ctx, orderNo, EventOrderLineItemAdded, func(items []LineItem) ([]LineItem, error) {
+		item, err := transientLineItem(ctx)
+		if err != nil {
+			return nil, err
+		}
+		item.LineNo = 0
+		return append(append([]LineItem{}, items...), item), nil
+	})
+}
+
+// AmendLineItem replaces the private line item with the given line number and recomputes
+// the order totals. The new line item is passed in the transient map under "private"
+func (s *SmartContract) AmendLineItem(ctx contractapi.TransactionContextInterface, orderNo string, lineNo int) error {
+	return s.changeLineItems(ctx, orderNo, EventOrderLineItemAmended, func(items []LineItem) ([]LineItem, error) {
+		item, err := transientLineItem(ctx)
+		if err != nil {
+			return nil, err
+		}
+		items = append([]LineItem{}, items...)
+		for i := range items {
+			if items[i].LineNo == lineNo {
+				item.LineNo = lineNo
+				items[i] = item
+				return items, nil
+			}
+		}
+		return nil, newError(CodeNotFound, map[string]string{"type": orderObjectType, "id": orderNo, "lineNo": strconv.Itoa(lineNo)}, "the order %s has no line %d", orderNo, lineNo)
+	})
+}
+
+// RemoveLineItem removes the private line item with the given line number and recomputes
+// the order totals
+func (s *SmartContract) RemoveLineItem(ctx contractapi.TransactionContextInterface, orderNo string, lineNo int) error {
+	return s.changeLineItems(ctx, orderNo, EventOrderLineItemRemoved, func(existing []LineItem) ([]LineItem, error) {
+		items := []LineItem{}
+		for _, item := range existing {
+			if item.LineNo != lineNo {
+				items = append(items, item)
+			}
+		}
+		if len(items) == len(existing) {
+			return nil, newError(CodeNotFound, map[string]string{"type": orderObjectType, "id": orderNo, "lineNo": strconv.Itoa(lineNo)}, "the order %s has no line %d", orderNo, lineNo)
+		}
+		return items, nil
+	})
+}
+
+// transientLineItem returns the line item passed in the transient map under "private"
+func transientLineItem(ctx contractapi.TransactionContextInterface) (LineItem, error) {
+	data, err := transientPrivate(ctx)
+	if err != nil {
+		return LineItem{}, err
+	}
+	if data == nil {
+		return LineItem{}, invalidArgument("the line item is private, pass it in the transient map under %q", privateTransientKey)
+	}
+
+	var item LineItem
+	if err := decodePrivate(data, &item); err != nil {
+		return LineItem{}, err
+	}
+	return item, nil
+}
+
+// changeLineItems replaces the private line items of an order by the ones returned by
+// change and records the mutation under the given action. The line items are read from
+// the orderPrivateDetails collection, so the endorsing peers must be members of it
+func (s *SmartContract) changeLineItems(ctx contractapi.TransactionContextInterface, orderNo string, action string, change func(items []LineItem) ([]LineItem, error)) error {
+	// Record the transaction timestamp
+	timestamp, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	logger := newLogger(ctx)
+
+	// Log the start of the function
+	logger.Debug("Changing line items", "orderNo", orderNo, "action", action)
+
+	// Validate the arguments
+	err = validate(ctx,
+		idArg("orderNo", orderNo),
+	)
+	if err != nil {
+		return err
+	}
+
+	order, err := s.ReadOrder(ctx, orderNo)
+	if err != nil {
+		return err
+	}
+
+	// Line items follow the same rule as the other order details
+	if status := order.lifecycleStatus(); isFinal(status) {
+		return finalStatus(orderObjectType, orderNo, string(status))
+	}
+	if order.PrivateHash == "" {
+		return invalidArgument("the order %s has no private line items, pass them to UpdateOrder in the transient map under %q",
+			orderNo, privateTransientKey)
+	}
+
+	var details OrderPrivateDetails
+	err = readPrivate(ctx, orderPrivateCollection, orderObjectType, orderNo, &details)
+	if err != nil {
+		return err
+	}
+
+	items, err := change(details.LineItems)
+	if err != nil {
+		return err
+	}
+	err = details.setLineItems(items)
+	if err != nil {
+		return err
+	}
+
+	// Keep the private details out of the public world state
+	order.PrivateHash, err = putPrivate(ctx, orderPrivateCollection, orderObjectType, orderNo, &details)
+	if err != nil {
+		return err
+	}
+	order.DocType = orderObjectType
+	order.UpdatedAt = timestamp
+	order.Version++
+
+	orderJSON, err := json.Marshal(order)
+	if err != nil {
+		return err
+	}
+
+	key, err := assetKey(ctx, orderObjectType, orderNo)
+	if err != nil {
+		return err
+	}
+
+	previousJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return internalError("failed to read order %s from world state: %v", orderNo, err)
+	}
+
+	err = ctx.GetStub().PutState(key, orderJSON)
+	if err != nil {
+		return internalError("failed to update order %s in world state: %v", orderNo, err)
+	}
+
+	// Publish order event
+	err = recordMutation(ctx, action, orderObjectType, orderNo, previousJSON, orderJSON)
+	if err != nil {
+		return err
+	}
+
+	// Log the success of the operation
+	logger.Info("Line items changed", "orderNo", orderNo, "action", action, "lines", len(details.LineItems))
+
+	return nil
+}
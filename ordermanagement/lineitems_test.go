@@ -0,0 +1,275 @@
+package ordermanagement
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// eur returns an amount in euro cents
+func eur(cents int64) *Money {
+	return &Money{Amount: cents, Currency: "EUR"}
+}
+
+// pallet returns a line item of quantity pallets at 120.50 EUR each with 20% tax
+func pallet(quantity float64) LineItem {
+	return LineItem{SKU: "PAL-1", Description: "Euro pallet", Quantity: quantity, Unit: "pcs", UnitPrice: *eur(12050), TaxRate: 20}
+}
+
+// itemsPrivate returns the private details of an order with the given line items
+func itemsPrivate(t *testing.T, orderNo string, items ...LineItem) string {
+	t.Helper()
+	private, err := json.Marshal(OrderPrivateDetails{Invoice: "INV-" + orderNo, LineItems: items, Salt: testSalt})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(private)
+}
+
+// itemPrivate returns a line item encoded for the transient map
+func itemPrivate(t *testing.T, item LineItem) string {
+	t.Helper()
+	private, err := json.Marshal(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(private)
+}
+
+// createOrderWithItems creates an order with the given private line items as a seller
+func (e *testEnv) createOrderWithItems(orderNo string, items ...LineItem) error {
+	return e.createPrivateOrder(orderNo, itemsPrivate(e.t, orderNo, items...))
+}
+
+// privateDetails reads the private details of an order as a seller
+func (e *testEnv) privateDetails(orderNo string) *OrderPrivateDetails {
+	e.t.Helper()
+	var details *OrderPrivateDetails
+	e.mustSubmit(RoleSeller, "ReadOrderPrivateDetails", func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		details, err = e.contract.ReadOrderPrivateDetails(ctx, orderNo)
+		return err
+	})
+	return details
+}
+
+func TestCreateOrderLineItems(t *testing.T) {
+	env := newTestEnv(t)
+	strap := LineItem{SKU: "STR-9", Description: "Strap", Quantity: 3, Unit: "m", UnitPrice: *eur(99), TaxRate: 5.5, Total: eur(313)}
+	if err := env.createOrderWithItems("ORD-1", pallet(10), strap); err != nil {
+		t.Fatal(err)
+	}
+
+	order := env.privateDetails("ORD-1")
+	if len(order.LineItems) != 2 {
+		t.Fatalf("got %d line items, want 2", len(order.LineItems))
+	}
+	first, second := order.LineItems[0], order.LineItems[1]
+	if first.LineNo != 1 || *first.Subtotal != *eur(120500) || *first.Tax != *eur(24100) || *first.Total != *eur(144600) {
+		t.Errorf("unexpected first line: %+v", first)
+	}
+	if second.LineNo != 2 || *second.Subtotal != *eur(297) || *second.Tax != *eur(16) || *second.Total != *eur(313) {
+		t.Errorf("unexpected second line: %+v", second)
+	}
+	if *order.Subtotal != *eur(120797) || *order.Tax != *eur(24116) || *order.Total != *eur(144913) {
+		t.Errorf("unexpected order totals: %s %s %s", order.Subtotal, order.Tax, order.Total)
+	}
+}
+
+func TestCreateOrderInvalidLineItems(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(item *LineItem)
+		items  []LineItem
+		want   string
+	}{
+		{name: "missing SKU", change: func(item *LineItem) { item.SKU = "" }, want: "SKU"},
+		{name: "zero quantity", change: func(item *LineItem) { item.Quantity = 0 }, want: "quantity"},
+		{name: "missing unit", change: func(item *LineItem) { item.Unit = "" }, want: "unit"},
+		{name: "negative price", change: func(item *LineItem) { item.UnitPrice.Amount = -1 }, want: "unit price"},
+		{name: "tax rate above 100", change: func(item *LineItem) { item.TaxRate = 120 }, want: "tax rate"},
+		{name: "lower case currency", change: func(item *LineItem) { item.UnitPrice.Currency = "eur" }, want: "currency"},
+		{name: "wrong subtotal", change: func(item *LineItem) { item.Subtotal = eur(120000) }, want: "subtotal"},
+		{name: "wrong tax", change: func(item *LineItem) { item.Tax = eur(24000) }, want: "tax"},
+		{name: "wrong total", change: func(item *LineItem) { item.Total = eur(144599) }, want: "total"},
+		{name: "total in another currency", change: func(item *LineItem) { item.Total = &Money{Amount: 144600, Currency: "USD"} }, want: "total"},
+		{name: "mixed currencies", items: []LineItem{pallet(1), {SKU: "X", Quantity: 1, Unit: "pcs", UnitPrice: Money{Currency: "USD"}}}, want: "must be in EUR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			items := tt.items
+			if tt.change != nil {
+				item := pallet(10)
+				tt.change(&item)
+				items = []LineItem{item}
+			}
+
+			err := env.createOrderWithItems("ORD-1", items...)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got error %v, want one mentioning %q", err, tt.want)
+			}
+			if env.stub.CommittedState(orderKey(t, env, "ORD-1")) != nil {
+				t.Error("the order was created")
+			}
+		})
+	}
+}
+
+func TestBareNumberPriceRejected(t *testing.T) {
+	env := newTestEnv(t)
+	bare := `{"invoice":"INV-ORD-1","lineItems":[{"sku":"PAL-1","quantity":1,"unit":"pcs","unitPrice":12.5}],"salt":"` + testSalt + `"}`
+	if err := env.createPrivateOrder("ORD-1", bare); errorCode(err) != CodeInvalidArgument {
+		t.Errorf("order with a bare number price: got %v", err)
+	}
+
+	if err := env.createOrderWithItems("ORD-2", pallet(1)); err != nil {
+		t.Fatal(err)
+	}
+	err := env.submitPrivate(RoleSeller, "AddLineItem", `{"sku":"PAL-1","quantity":1,"unit":"pcs","unitPrice":12.5}`, func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.AddLineItem(ctx, "ORD-2")
+	})
+	if errorCode(err) != CodeInvalidArgument {
+		t.Errorf("line item with a bare number price: got %v", err)
+	}
+}
+
+// orderKey returns the world state key of an order
+func orderKey(t *testing.T, env *testEnv, orderNo string) string {
+	key, err := env.stub.CreateCompositeKey(orderObjectType, []string{orderNo})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestUpdateOrderReplacesLineItems(t *testing.T) {
+	env := newTestEnv(t)
+	if err := env.createOrderWithItems("ORD-1", pallet(10), pallet(2)); err != nil {
+		t.Fatal(err)
+	}
+
+	err := env.submitPrivate(RoleSeller, "UpdateOrder", itemsPrivate(t, "ORD-1", pallet(1)), func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.UpdateOrder(ctx, "ORD-1", "2024-03-02", "pallets", "ACH")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	order := env.privateDetails("ORD-1")
+	if len(order.LineItems) != 1 || order.LineItems[0].LineNo != 1 || *order.Total != *eur(14460) {
+		t.Errorf("unexpected order after update: %d lines, total %s", len(order.LineItems), order.Total)
+	}
+}
+
+func TestChangeLineItems(t *testing.T) {
+	env := newTestEnv(t)
+	if err := env.createOrderWithItems("ORD-1", pallet(10)); err != nil {
+		t.Fatal(err)
+	}
+
+	hash := env.readOrder("ORD-1").PrivateHash
+	err := env.submitPrivate(RoleSeller, "AddLineItem", itemPrivate(t, pallet(1)), func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.AddLineItem(ctx, "ORD-1")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order := env.privateDetails("ORD-1"); len(order.LineItems) != 2 || order.LineItems[1].LineNo != 2 || *order.Total != *eur(159060) {
+		t.Errorf("unexpected order after add: %+v", order.LineItems)
+	}
+	if event := env.lastEvent(); event.Name != EventOrderLineItemAdded {
+		t.Errorf("event = %s, want %s", event.Name, EventOrderLineItemAdded)
+	}
+	if order := env.readOrder("ORD-1"); order.PrivateHash == hash || order.LineItems != nil || order.Version != 2 {
+		t.Errorf("the public order does not reflect the new private details: %+v", order)
+	}
+
+	err = env.submitPrivate(RoleSeller, "AmendLineItem", itemPrivate(t, pallet(5)), func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.AmendLineItem(ctx, "ORD-1", 1)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order := env.privateDetails("ORD-1"); order.LineItems[0].LineNo != 1 || order.LineItems[0].Quantity != 5 || *order.Total != *eur(86760) {
+		t.Errorf("unexpected order after amend: %+v", order.LineItems)
+	}
+
+	env.mustSubmit(RoleSeller, "RemoveLineItem", func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.RemoveLineItem(ctx, "ORD-1", 1)
+	})
+	order := env.privateDetails("ORD-1")
+	if len(order.LineItems) != 1 || order.LineItems[0].LineNo != 2 || *order.Total != *eur(14460) {
+		t.Errorf("unexpected order after remove: %+v", order.LineItems)
+	}
+
+	actions := []string{}
+	for _, entry := range env.auditTrail("order:ORD-1") {
+		actions = append(actions, entry.Action)
+	}
+	if strings.Join(actions, ",") != "OrderCreated,OrderLineItemAdded,OrderLineItemAmended,OrderLineItemRemoved" {
+		t.Errorf("unexpected audit trail: %v", actions)
+	}
+}
+
+func TestChangeLineItemsErrors(t *testing.T) {
+	env := newTestEnv(t)
+	if err := env.createOrderWithItems("ORD-1", pallet(10)); err != nil {
+		t.Fatal(err)
+	}
+
+	env.createOrder("ORD-3")
+
+	tests := []struct {
+		name     string
+		function string
+		private  string
+		call     func(ctx contractapi.TransactionContextInterface) error
+		want     string
+	}{
+		{"amend a missing line", "AmendLineItem", itemPrivate(t, pallet(1)), func(ctx contractapi.TransactionContextInterface) error {
+			return env.contract.AmendLineItem(ctx, "ORD-1", 7)
+		}, "no line 7"},
+		{"remove a missing line", "RemoveLineItem", "", func(ctx contractapi.TransactionContextInterface) error {
+			return env.contract.RemoveLineItem(ctx, "ORD-1", 7)
+		}, "no line 7"},
+		{"add an invalid item", "AddLineItem", itemPrivate(t, LineItem{SKU: "X", Quantity: 1, Unit: "pcs", UnitPrice: Money{Currency: "USD"}}), func(ctx contractapi.TransactionContextInterface) error {
+			return env.contract.AddLineItem(ctx, "ORD-1")
+		}, "must be in EUR"},
+		{"add a misspelt item", "AddLineItem", `{"sku":"PAL-1","qty":1}`, func(ctx contractapi.TransactionContextInterface) error {
+			return env.contract.AddLineItem(ctx, "ORD-1")
+		}, "invalid private details"},
+		{"add to a missing order", "AddLineItem", itemPrivate(t, pallet(1)), func(ctx contractapi.TransactionContextInterface) error {
+			return env.contract.AddLineItem(ctx, "ORD-2")
+		}, "does not exist"},
+		{"add to an order without private details", "AddLineItem", itemPrivate(t, pallet(1)), func(ctx contractapi.TransactionContextInterface) error {
+			return env.contract.AddLineItem(ctx, "ORD-3")
+		}, "has no private line items"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := env.submitPrivate(RoleSeller, tt.function, tt.private, tt.call)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got error %v, want one mentioning %q", err, tt.want)
+			}
+		})
+	}
+
+	if order := env.privateDetails("ORD-1"); len(order.LineItems) != 1 || *order.Total != *eur(144600) {
+		t.Errorf("a rejected change was applied: %+v", order.LineItems)
+	}
+
+	env.mustSubmit(RoleBuyer, "CancelOrder", func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.CancelOrder(ctx, "ORD-1", "")
+	})
+	err := env.submitPrivate(RoleSeller, "AddLineItem", itemPrivate(t, pallet(1)), func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.AddLineItem(ctx, "ORD-1")
+	})
+	if err == nil || !strings.Contains(err.Error(), "can no longer be updated") {
+		t.Errorf("got error %v, want a final status error", err)
+	}
+}
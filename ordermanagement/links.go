@@ -0,0 +1,254 @@
+package ordermanagement
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Object types of the reverse indexes listing the payments and shipments of an order
+const (
+	orderPaymentIndex  = "order~payment"
+	orderShipmentIndex = "order~shipment"
+)
+
+// OrderDossier structure used for returning an order together with its linked records
+type OrderDossier struct {
+	Order     *Order            `json:"order"`
+	Payments  []TransactionData `json:"payments"`
+	Shipments []ShipEngineData  `json:"shipments"`
+}
+
+// linkToOrder checks that the referenced order exists and is not archived and indexes
+// the record under it
+func linkToOrder(ctx contractapi.TransactionContextInterface, index string, orderNo string, id string) error {
+	if orderNo == "" {
+		return invalidArgument("%s must reference an order", id)
+	}
+
+	orderKey, err := assetKey(ctx, orderObjectType, orderNo)
+	if err != nil {
+		return err
+	}
+	orderJSON, err := ctx.GetStub().GetState(orderKey)
+	if err != nil {
+		return internalError("failed to read order %s from world state: %v", orderNo, err)
+	}
+	if orderJSON == nil {
+		return newError(CodeNotFound, map[string]string{"type": orderObjectType, "id": orderNo}, "the order %s referenced by %s does not exist", orderNo, id)
+	}
+
+	// Archived and voided orders take no new records until they are restored
+	var order Order
+	err = json.Unmarshal(orderJSON, &order)
+	if err != nil {
+		return internalError("failed to decode order %s: %v", orderNo, err)
+	}
+	if status := order.lifecycleStatus(); isArchived(status) {
+		return finalStatus(orderObjectType, orderNo, string(status))
+	}
+
+	return putLink(ctx, index, orderNo, id)
+}
+
+// putLink indexes a record under an order
+func putLink(ctx contractapi.TransactionContextInterface, index string, orderNo string, id string) error {
+	indexKey, err := ctx.GetStub().CreateCompositeKey(index, []string{orderNo, id})
+	if err != nil {
+		return invalidArgument("failed to create %s key for %s: %v", index, id, err)
+	}
+
+	// The index entry carries no data, the key alone links the record to the order
+	err = ctx.GetStub().PutState(indexKey, []byte{0x00})
+	if err != nil {
+		return internalError("failed to link %s to order %s: %v", id, orderNo, err)
+	}
+	return nil
+}
+
+// linkedIDs returns the IDs of the records indexed under an order
+func linkedIDs(ctx contractapi.TransactionContextInterface, index string, orderNo string) ([]string, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(index, []string{orderNo})
+	if err != nil {
+		return nil, internalError("failed to read %s index of order %s: %v", index, orderNo, err)
+	}
+	defer resultsIterator.Close()
+
+	ids := []string{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, internalError("failed to read query results: %v", err)
+		}
+
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, internalError("failed to split index key %s: %v", queryResponse.Key, err)
+		}
+		ids = append(ids, attributes[1])
+	}
+
+	return ids, nil
+}
+
+// readLinked unmarshals the record of the given object type and ID into value
+func readLinked(ctx contractapi.TransactionContextInterface, objectType string, id string, value interface{}) error {
+	key, err := assetKey(ctx, objectType, id)
+	if err != nil {
+		return err
+	}
+
+	dataBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return internalError("failed to read %s %s from world state: %v", objectType, id, err)
+	}
+	if dataBytes == nil {
+		return notFound(objectType, id)
+	}
+
+	err = json.Unmarshal(dataBytes, value)
+	if err != nil {
+		return internalError("failed to decode %s %s: %v", objectType, id, err)
+	}
+	return nil
+}
+
+// orderPayments returns the payments linked to an order
+func orderPayments(ctx contractapi.TransactionContextInterface, orderNo string) ([]TransactionData, error) {
+	ids, err := linkedIDs(ctx, orderPaymentIndex, orderNo)
+	if err != nil {
+		return nil, err
+	}
+
+	payments := []TransactionData{}
+	for _, id := range ids {
+		var payment TransactionData
+		if err := readLinked(ctx, paymentObjectType, id, &payment); err != nil {
+			return nil, err
+		}
+		payments = append(payments, payment)
+	}
+	return payments, nil
+}
+
+// orderShipments returns the shipments linked to an order
+func orderShipments(ctx contractapi.TransactionContextInterface, orderNo string) ([]ShipEngineData, error) {
+	ids, err := linkedIDs(ctx, orderShipmentIndex, orderNo)
+	if err != nil {
+		return nil, err
+	}
+
+	shipments := []ShipEngineData{}
+	for _, id := range ids {
+		var shipment ShipEngineData
+		if err := readLinked(ctx, shipmentObjectType, id, &shipment); err != nil {
+			return nil, err
+		}
+		shipments = append(shipments, shipment)
+	}
+	return shipments, nil
+}
+
+// GetOrderPayments returns the payments made for an order
+func (s *SmartContract) GetOrderPayments(ctx contractapi.TransactionContextInterface, orderNo string) ([]TransactionData, error) {
+	logger := newLogger(ctx)
+
+	// Log the start of the function
+	logger.Debug("Reading payments of order", "orderNo", orderNo)
+
+	// Validate the arguments
+	err := validate(ctx,
+		idArg("orderNo", orderNo),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := s.OrderExists(ctx, orderNo)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, notFound(orderObjectType, orderNo)
+	}
+
+	payments, err := orderPayments(ctx, orderNo)
+	if err != nil {
+		return nil, err
+	}
+
+	// Log the success of the operation
+	logger.Info("Payments of order read", "orderNo", orderNo, "count", len(payments))
+
+	return payments, nil
+}
+
+// GetOrderShipments returns the shipments of an order
+func (s *SmartContract) GetOrderShipments(ctx contractapi.TransactionContextInterface, orderNo string) ([]ShipEngineData, error) {
+	logger := newLogger(ctx)
+
+	// Log the start of the function
+	logger.Debug("Reading shipments of order", "orderNo", orderNo)
+
+	// Validate the arguments
+	err := validate(ctx,
+		idArg("orderNo", orderNo),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := s.OrderExists(ctx, orderNo)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, notFound(orderObjectType, orderNo)
+	}
+
+	shipments, err := orderShipments(ctx, orderNo)
+	if err != nil {
+		return nil, err
+	}
+
+	// Log the success of the operation
+	logger.Info("Shipments of order read", "orderNo", orderNo, "count", len(shipments))
+
+	return shipments, nil
+}
+
+// GetOrderDossier returns an order together with all its payments and shipments
+func (s *SmartContract) GetOrderDossier(ctx contractapi.TransactionContextInterface, orderNo string) (*OrderDossier, error) {
+	logger := newLogger(ctx)
+
+	// Log the start of the function
+	logger.Debug("Reading order dossier", "orderNo", orderNo)
+
+	// Validate the arguments
+	err := validate(ctx,
+		idArg("orderNo", orderNo),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := s.ReadOrder(ctx, orderNo)
+	if err != nil {
+		return nil, err
+	}
+
+	payments, err := orderPayments(ctx, orderNo)
+	if err != nil {
+		return nil, err
+	}
+
+	shipments, err := orderShipments(ctx, orderNo)
+	if err != nil {
+		return nil, err
+	}
+
+	// Log the success of the operation
+	logger.Info("Order dossier read", "orderNo", orderNo, "payments", len(payments), "shipments", len(shipments))
+
+	return &OrderDossier{Order: order, Payments: payments, Shipments: shipments}, nil
+}
@@ -0,0 +1,120 @@
+package ordermanagement
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func TestGetOrderDossier(t *testing.T) {
+	env := newTestEnv(t)
+	env.createOrder("ORD-1")
+	env.createOrder("ORD-2")
+	env.createPayment("PAY-1", "ORD-1")
+	env.createPayment("PAY-2", "ORD-1")
+	env.createPayment("PAY-3", "ORD-2")
+	env.createShipment("SHP-1", "ORD-1")
+
+	var dossier *OrderDossier
+	env.mustSubmit(RoleAuditor, "GetOrderDossier", func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		dossier, err = env.contract.GetOrderDossier(ctx, "ORD-1")
+		return err
+	})
+
+	if dossier.Order.OrderNo != "ORD-1" {
+		t.Errorf("dossier of order %s", dossier.Order.OrderNo)
+	}
+	if len(dossier.Payments) != 2 || dossier.Payments[0].ID != "PAY-1" || dossier.Payments[1].ID != "PAY-2" {
+		t.Errorf("unexpected payments: %+v", dossier.Payments)
+	}
+	if len(dossier.Shipments) != 1 || dossier.Shipments[0].ID != "SHP-1" || dossier.Shipments[0].OrderNo != "ORD-1" {
+		t.Errorf("unexpected shipments: %+v", dossier.Shipments)
+	}
+
+	env.mustSubmit(RoleAuditor, "GetOrderDossier", func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		dossier, err = env.contract.GetOrderDossier(ctx, "ORD-2")
+		return err
+	})
+	if len(dossier.Payments) != 1 || dossier.Shipments == nil || len(dossier.Shipments) != 0 {
+		t.Errorf("unexpected dossier of ORD-2: %+v", dossier)
+	}
+}
+
+func TestGetOrderPaymentsAndShipments(t *testing.T) {
+	env := newTestEnv(t)
+	env.createOrder("ORD-1")
+	env.createPayment("PAY-1", "ORD-1")
+	env.createShipment("SHP-1", "ORD-1")
+	env.createShipment("SHP-2", "ORD-1")
+
+	var payments []TransactionData
+	env.mustSubmit(RoleAuditor, "GetOrderPayments", func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		payments, err = env.contract.GetOrderPayments(ctx, "ORD-1")
+		return err
+	})
+	if len(payments) != 1 || payments[0].ID != "PAY-1" || payments[0].OrderNo != "ORD-1" {
+		t.Errorf("unexpected payments: %+v", payments)
+	}
+
+	var shipments []ShipEngineData
+	env.mustSubmit(RoleAuditor, "GetOrderShipments", func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		shipments, err = env.contract.GetOrderShipments(ctx, "ORD-1")
+		return err
+	})
+	if len(shipments) != 2 || shipments[0].ID != "SHP-1" || shipments[1].ID != "SHP-2" {
+		t.Errorf("unexpected shipments: %+v", shipments)
+	}
+
+	for function, call := range map[string]func(ctx contractapi.TransactionContextInterface) error{
+		"GetOrderPayments": func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.contract.GetOrderPayments(ctx, "ORD-2")
+			return err
+		},
+		"GetOrderShipments": func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.contract.GetOrderShipments(ctx, "ORD-2")
+			return err
+		},
+		"GetOrderDossier": func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.contract.GetOrderDossier(ctx, "ORD-2")
+			return err
+		},
+	} {
+		if err := env.submit(RoleAuditor, function, call); err == nil || !strings.Contains(err.Error(), "does not exist") {
+			t.Errorf("%s of a missing order: got error %v", function, err)
+		}
+	}
+}
+
+func TestLinkToMissingOrder(t *testing.T) {
+	env := newTestEnv(t)
+	err := env.submit(RoleCarrier, "CreateShipEngineData", func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.CreateShipEngineData(ctx, "SHP-1", "ORD-1", "se-1", "https://track.example.com/se-1")
+	})
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("got error %v, want a missing order error", err)
+	}
+	if env.stub.CommittedState(orderKey(t, env, "ORD-1")) != nil {
+		t.Error("an order was created")
+	}
+}
+
+func TestDeleteLinkedOrder(t *testing.T) {
+	env := newTestEnv(t)
+	env.createOrder("ORD-1")
+	env.createShipment("SHP-1", "ORD-1")
+
+	err := env.submit(RoleSeller, "DeleteOrder", func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.DeleteOrder(ctx, "ORD-1")
+	})
+	if err == nil || !strings.Contains(err.Error(), "SHP-1") {
+		t.Errorf("got error %v, want a referenced order error", err)
+	}
+	if env.stub.CommittedState(orderKey(t, env, "ORD-1")) == nil {
+		t.Error("the order was deleted")
+	}
+}
@@ -0,0 +1,159 @@
+package ordermanagement
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// logLevelEnv is the environment variable selecting the lowest level logged: DEBUG, INFO,
+// WARN or ERROR. INFO is used when it is unset or invalid
+const logLevelEnv = "CHAINCODE_LOG_LEVEL"
+
+// logLevel is the severity of a log line
+type logLevel int
+
+const (
+	levelDebug logLevel = iota
+	levelInfo
+	levelWarn
+	levelError
+)
+
+var levelNames = map[logLevel]string{
+	levelDebug: "DEBUG",
+	levelInfo:  "INFO",
+	levelWarn:  "WARN",
+	levelError: "ERROR",
+}
+
+// parseLogLevel returns the level with the given name, or INFO for unknown names
+func parseLogLevel(name string) logLevel {
+	for level, levelName := range levelNames {
+		if strings.EqualFold(strings.TrimSpace(name), levelName) {
+			return level
+		}
+	}
+	return levelInfo
+}
+
+// Log output shared by all transactions. The level is read once when the chaincode starts
+var (
+	logMutex    sync.Mutex
+	logOutput   io.Writer = os.Stdout
+	minLogLevel           = parseLogLevel(os.Getenv(logLevelEnv))
+)
+
+// redactedFields are the parameters that never reach the logs in clear. Accounts keep
+// their last four characters so that support can still tell them apart
+var redactedFields = map[string]func(value string) string{
+	"account":            maskAccount,
+	"invoice":            redact,
+	"orderDetail":        redact,
+	"transactionDetails": redact,
+	"reason":             redact,
+	"description":        redact,
+}
+
+// redact hides a value entirely
+func redact(value string) string {
+	if value == "" {
+		return ""
+	}
+	return "[REDACTED]"
+}
+
+// maskAccount hides all but the last four characters of an account
+func maskAccount(value string) string {
+	if len(value) <= 4 {
+		return redact(value)
+	}
+	return strings.Repeat("*", len(value)-4) + value[len(value)-4:]
+}
+
+// txLogger writes JSON log lines carrying the transaction ID, time, channel, function and
+// MSP ID of the caller, so that the lines of a transaction can be correlated across peers.
+// The time is the proposal time of the transaction, the same on every peer, while
+// peerTime is the clock of the peer writing the line
+type txLogger struct {
+	fields map[string]interface{}
+}
+
+// newLogger returns the logger of the current transaction
+func newLogger(ctx contractapi.TransactionContextInterface) *txLogger {
+	function, _ := ctx.GetStub().GetFunctionAndParameters()
+	fields := map[string]interface{}{
+		"txId":     ctx.GetStub().GetTxID(),
+		"channel":  ctx.GetStub().GetChannelID(),
+		"function": functionName(function),
+	}
+	if mspID, err := ctx.GetClientIdentity().GetMSPID(); err == nil {
+		fields["mspId"] = mspID
+	}
+	if t, err := txTime(ctx); err == nil {
+		fields["time"] = t.Format(time.RFC3339Nano)
+	}
+	return &txLogger{fields: fields}
+}
+
+// Debug logs the details of a call, such as its parameters
+func (l *txLogger) Debug(message string, keyvals ...interface{}) {
+	l.log(levelDebug, message, keyvals)
+}
+
+// Info logs the outcome of a call
+func (l *txLogger) Info(message string, keyvals ...interface{}) {
+	l.log(levelInfo, message, keyvals)
+}
+
+// Warn logs an unexpected but handled condition
+func (l *txLogger) Warn(message string, keyvals ...interface{}) {
+	l.log(levelWarn, message, keyvals)
+}
+
+// Error logs a failure
+func (l *txLogger) Error(message string, keyvals ...interface{}) {
+	l.log(levelError, message, keyvals)
+}
+
+// log writes a line with the transaction fields and the given key value pairs. Values of
+// the redacted fields are masked
+func (l *txLogger) log(level logLevel, message string, keyvals []interface{}) {
+	if level < minLogLevel {
+		return
+	}
+
+	line := make(map[string]interface{}, len(l.fields)+len(keyvals)/2+3)
+	for key, value := range l.fields {
+		line[key] = value
+	}
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		key := fmt.Sprint(keyvals[i])
+		value := keyvals[i+1]
+		if mask, ok := redactedFields[key]; ok {
+			value = mask(fmt.Sprint(value))
+		}
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+		line[key] = value
+	}
+	line["peerTime"] = time.Now().UTC().Format(time.RFC3339Nano)
+	line["level"] = levelNames[level]
+	line["msg"] = message
+
+	lineJSON, err := json.Marshal(line)
+	if err != nil {
+		lineJSON, _ = json.Marshal(map[string]string{"level": levelNames[levelError], "msg": "failed to encode log line: " + err.Error()})
+	}
+
+	logMutex.Lock()
+	defer logMutex.Unlock()
+	fmt.Fprintln(logOutput, string(lineJSON))
+}
@@ -0,0 +1,129 @@
+package ordermanagement
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// captureLogs sends the log lines written at or above the given level to a buffer for
+// the rest of the test
+func captureLogs(t *testing.T, level logLevel) *bytes.Buffer {
+	t.Helper()
+	var buffer bytes.Buffer
+	output, original := logOutput, minLogLevel
+	logOutput, minLogLevel = &buffer, level
+	t.Cleanup(func() { logOutput, minLogLevel = output, original })
+	return &buffer
+}
+
+// logLines decodes the captured JSON log lines
+func logLines(t *testing.T, buffer *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var lines []map[string]interface{}
+	scanner := bufio.NewScanner(buffer)
+	for scanner.Scan() {
+		var line map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("log line %q is not JSON: %v", scanner.Text(), err)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestLogFields(t *testing.T) {
+	env := newTestEnv(t)
+	env.createOrder("ORD-1")
+	buffer := captureLogs(t, levelDebug)
+
+	env.createPayment("PAY-1", "ORD-1")
+
+	lines := logLines(t, buffer)
+	if len(lines) < 2 {
+		t.Fatalf("got %d log lines, want the start and the outcome of the call", len(lines))
+	}
+	for _, line := range lines {
+		if line["txId"] != "tx2" || line["channel"] != "mychannel" || line["function"] != "CreateTransaction" || line["mspId"] != testMSPID(RoleBank) {
+			t.Errorf("log line misses the transaction fields: %v", line)
+		}
+		if line["time"] != "2024-03-01T10:01:00Z" {
+			t.Errorf("log line time = %v, want the proposal time of the transaction", line["time"])
+		}
+		if line["peerTime"] == nil || line["level"] == nil || line["msg"] == nil {
+			t.Errorf("log line misses the peer time, level or message: %v", line)
+		}
+	}
+
+	start := lines[0]
+	if start["level"] != "DEBUG" || start["id"] != "PAY-1" || start["amount"] != "100.00" {
+		t.Errorf("unexpected start line: %v", start)
+	}
+	if last := lines[len(lines)-1]; last["level"] != "INFO" || last["msg"] != "Payment created" {
+		t.Errorf("unexpected outcome line: %v", last)
+	}
+
+	// An account passed as a parameter is rejected, but logged masked first
+	buffer.Reset()
+	err := env.submit(RoleBank, "CreateTransaction", func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.CreateTransaction(ctx, "PAY-2", "ORD-1", "ACH", "100.00", "USD", "1234567890", "deposit")
+	})
+	if err == nil {
+		t.Fatal("a public account was accepted")
+	}
+	if start := logLines(t, buffer)[0]; start["account"] != "******7890" {
+		t.Errorf("account = %v, want it masked", start["account"])
+	}
+	if strings.Contains(buffer.String(), "1234567890") {
+		t.Error("the account number reached the logs")
+	}
+}
+
+func TestLogRedaction(t *testing.T) {
+	env := newTestEnv(t)
+	buffer := captureLogs(t, levelDebug)
+
+	env.createOrder("ORD-1")
+	start := logLines(t, buffer)[0]
+	if start["orderDetail"] != "[REDACTED]" || start["orderNo"] != "ORD-1" {
+		t.Errorf("unexpected start line: %v", start)
+	}
+
+	buffer.Reset()
+	env.mustSubmit(RoleAuditor, "QueryOrdersByInvoice", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.contract.QueryOrdersByInvoice(ctx, "INV-1")
+		return err
+	})
+	if start := logLines(t, buffer)[0]; start["invoice"] != "[REDACTED]" {
+		t.Errorf("unexpected start line: %v", start)
+	}
+}
+
+func TestLogLevel(t *testing.T) {
+	env := newTestEnv(t)
+	buffer := captureLogs(t, levelInfo)
+
+	env.createOrder("ORD-1")
+
+	lines := logLines(t, buffer)
+	if len(lines) != 1 || lines[0]["level"] != "INFO" {
+		t.Errorf("got log lines %v, want only the outcome of the call", lines)
+	}
+
+	tests := map[string]logLevel{
+		"debug":   levelDebug,
+		" WARN ":  levelWarn,
+		"Error":   levelError,
+		"":        levelInfo,
+		"verbose": levelInfo,
+	}
+	for name, want := range tests {
+		if got := parseLogLevel(name); got != want {
+			t.Errorf("parseLogLevel(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package ordermanagement
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// currencyPattern matches an ISO 4217 alphabetic currency code
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// decimalPattern matches a plain decimal number such as 120, 120.5 or -0.75
+var decimalPattern = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+// legacyCurrency is the currency of the payment amounts recorded before amounts carried one.
+// The original contract only took payments in US dollars
+const legacyCurrency = "USD"
+
+// currencyExponents lists the ISO 4217 currencies whose minor unit is not a hundredth
+var currencyExponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// Money is an exact amount of a currency, held as an integer number of minor units
+// (cents for EUR, yen for JPY, fils for KWD). It encodes to JSON as
+// {"amount":12050,"currency":"EUR"}, which is the same on every peer
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// currencyExponent returns the number of decimals of the minor unit of a currency
+func currencyExponent(currency string) int {
+	if exponent, ok := currencyExponents[currency]; ok {
+		return exponent
+	}
+	return 2
+}
+
+// ParseMoney parses a decimal amount of a currency, e.g. "120.50" and "EUR". The amount
+// may not have more decimals than the minor unit of the currency allows
+func ParseMoney(amount string, currency string) (Money, error) {
+	if !currencyPattern.MatchString(currency) {
+		return Money{}, fmt.Errorf("the currency must be an ISO 4217 code, got %q", currency)
+	}
+	if !decimalPattern.MatchString(amount) {
+		return Money{}, fmt.Errorf("the amount %q is not a decimal number", amount)
+	}
+
+	exponent := currencyExponent(currency)
+	whole, fraction := amount, ""
+	if i := strings.IndexByte(amount, '.'); i >= 0 {
+		whole, fraction = amount[:i], amount[i+1:]
+	}
+	if len(fraction) > exponent {
+		return Money{}, fmt.Errorf("the amount %s has more than %d decimals allowed for %s", amount, exponent, currency)
+	}
+
+	negative := strings.HasPrefix(whole, "-")
+	digits := strings.TrimPrefix(whole, "-") + fraction + strings.Repeat("0", exponent-len(fraction))
+	minor, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("the amount %s is out of range", amount)
+	}
+	if negative {
+		minor = -minor
+	}
+
+	return Money{Amount: minor, Currency: currency}, nil
+}
+
+// Decimal returns the amount as a decimal number with the decimals of the currency, e.g. 120.50
+func (m Money) Decimal() string {
+	exponent := currencyExponent(m.Currency)
+	sign := ""
+	minor := m.Amount
+	if minor < 0 {
+		sign = "-"
+	}
+	digits := strings.TrimPrefix(strconv.FormatInt(minor, 10), "-")
+	if exponent == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exponent] + "." + digits[len(digits)-exponent:]
+}
+
+// String returns the amount followed by the currency, e.g. 120.50 EUR
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+// Add returns the sum of two amounts of the same currency
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, fmt.Errorf("cannot add %s to %s", other.Currency, m.Currency)
+	}
+	sum := m.Amount + other.Amount
+	if (other.Amount > 0 && sum < m.Amount) || (other.Amount < 0 && sum > m.Amount) {
+		return Money{}, fmt.Errorf("the sum of %s and %s is out of range", m, other)
+	}
+	return Money{Amount: sum, Currency: m.Currency}, nil
+}
+
+// Mul returns the amount multiplied by an exact factor, rounded half away from zero to
+// the minor unit of the currency
+func (m Money) Mul(factor *big.Rat) (Money, error) {
+	product := new(big.Rat).Mul(new(big.Rat).SetInt64(m.Amount), factor)
+
+	num := new(big.Int).Abs(product.Num())
+	quotient, remainder := new(big.Int).QuoRem(num, product.Denom(), new(big.Int))
+	if new(big.Int).Lsh(remainder, 1).Cmp(product.Denom()) >= 0 {
+		quotient.Add(quotient, big.NewInt(1))
+	}
+	if product.Sign() < 0 {
+		quotient.Neg(quotient)
+	}
+
+	if !quotient.IsInt64() {
+		return Money{}, fmt.Errorf("%s multiplied by %s is out of range", m, factor.FloatString(6))
+	}
+	return Money{Amount: quotient.Int64(), Currency: m.Currency}, nil
+}
+
+// exactDecimal returns the decimal value of a float as written, e.g. 0.1 rather than
+// the nearest binary fraction
+func exactDecimal(value float64) (*big.Rat, error) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return nil, fmt.Errorf("%v is not a number", value)
+	}
+	rat, ok := new(big.Rat).SetString(strconv.FormatFloat(value, 'f', -1, 64))
+	if !ok {
+		return nil, fmt.Errorf("%v is not a decimal number", value)
+	}
+	return rat, nil
+}
+
+// legacyMoney returns an amount recorded as a bare number of US dollars before amounts
+// carried a currency, rounded half away from zero to the minor unit of the currency
+func legacyMoney(amount float64) (Money, error) {
+	exact, err := exactDecimal(amount)
+	if err != nil {
+		return Money{}, err
+	}
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(currencyExponent(legacyCurrency))), nil)
+	return Money{Amount: 1, Currency: legacyCurrency}.Mul(exact.Mul(exact, new(big.Rat).SetInt(scale)))
+}
@@ -0,0 +1,157 @@
+package ordermanagement
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		amount   string
+		currency string
+		want     Money
+		wantErr  bool
+	}{
+		{"120.50", "EUR", Money{12050, "EUR"}, false},
+		{"120.5", "EUR", Money{12050, "EUR"}, false},
+		{"120", "EUR", Money{12000, "EUR"}, false},
+		{"0.07", "USD", Money{7, "USD"}, false},
+		{"-3.10", "USD", Money{-310, "USD"}, false},
+		{"1500", "JPY", Money{1500, "JPY"}, false},
+		{"1.234", "KWD", Money{1234, "KWD"}, false},
+		{"92233720368547758.07", "USD", Money{math.MaxInt64, "USD"}, false},
+		{"92233720368547758.08", "USD", Money{}, true},
+		{"120.505", "EUR", Money{}, true},
+		{"1500.5", "JPY", Money{}, true},
+		{"1,50", "EUR", Money{}, true},
+		{"1e3", "EUR", Money{}, true},
+		{".5", "EUR", Money{}, true},
+		{"5.", "EUR", Money{}, true},
+		{" 5", "EUR", Money{}, true},
+		{"+5", "EUR", Money{}, true},
+		{"", "EUR", Money{}, true},
+		{"5", "eur", Money{}, true},
+		{"5", "EURO", Money{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.amount, tt.currency)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseMoney(%q, %q) error = %v, want error %t", tt.amount, tt.currency, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseMoney(%q, %q) = %+v, want %+v", tt.amount, tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyDecimal(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{Money{12050, "EUR"}, "120.50 EUR"},
+		{Money{5, "EUR"}, "0.05 EUR"},
+		{Money{-5, "EUR"}, "-0.05 EUR"},
+		{Money{0, "USD"}, "0.00 USD"},
+		{Money{1500, "JPY"}, "1500 JPY"},
+		{Money{1234, "KWD"}, "1.234 KWD"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.money.String(); got != tt.want {
+			t.Errorf("String() = %s, want %s", got, tt.want)
+		}
+		parsed, err := ParseMoney(tt.money.Decimal(), tt.money.Currency)
+		if err != nil || parsed != tt.money {
+			t.Errorf("%s does not parse back: %+v, %v", tt.want, parsed, err)
+		}
+	}
+}
+
+func TestMoneyMul(t *testing.T) {
+	tests := []struct {
+		money  Money
+		factor *big.Rat
+		want   int64
+	}{
+		{Money{99, "EUR"}, big.NewRat(3, 1), 297},
+		{Money{297, "EUR"}, big.NewRat(55, 1000), 16},
+		{Money{10, "EUR"}, big.NewRat(1, 4), 3},
+		{Money{-10, "EUR"}, big.NewRat(1, 4), -3},
+		{Money{10, "EUR"}, big.NewRat(1, 5), 2},
+		{Money{333, "JPY"}, big.NewRat(1, 2), 167},
+	}
+
+	for _, tt := range tests {
+		got, err := tt.money.Mul(tt.factor)
+		if err != nil || got.Amount != tt.want || got.Currency != tt.money.Currency {
+			t.Errorf("%s * %s = %s, %v, want %d", tt.money, tt.factor, got, err, tt.want)
+		}
+	}
+
+	if _, err := (Money{math.MaxInt64, "EUR"}).Mul(big.NewRat(2, 1)); err == nil {
+		t.Error("overflow was not detected")
+	}
+}
+
+func TestMoneyAdd(t *testing.T) {
+	sum, err := Money{150, "EUR"}.Add(Money{275, "EUR"})
+	if err != nil || sum != (Money{425, "EUR"}) {
+		t.Errorf("got %+v, %v", sum, err)
+	}
+	if _, err := (Money{150, "EUR"}).Add(Money{275, "USD"}); err == nil {
+		t.Error("added amounts of different currencies")
+	}
+	if _, err := (Money{math.MaxInt64, "EUR"}).Add(Money{1, "EUR"}); err == nil {
+		t.Error("overflow was not detected")
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	data, err := json.Marshal(Money{12050, "EUR"})
+	if err != nil || string(data) != `{"amount":12050,"currency":"EUR"}` {
+		t.Errorf("got %s, %v", data, err)
+	}
+
+	var decoded Money
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded != (Money{12050, "EUR"}) {
+		t.Errorf("got %+v, %v", decoded, err)
+	}
+	if err := json.Unmarshal([]byte(`100.5`), &decoded); err == nil {
+		t.Error("a bare number was accepted")
+	}
+	if err := json.Unmarshal([]byte(`"100.5"`), &decoded); err == nil {
+		t.Error("a string amount was accepted")
+	}
+}
+
+func TestLegacyPaymentAmount(t *testing.T) {
+	tests := []struct {
+		amount string
+		want   Money
+	}{
+		{`100.5`, Money{10050, "USD"}},
+		{`1.005`, Money{101, "USD"}},
+		{`0.1`, Money{10, "USD"}},
+		{`{"amount":12050,"currency":"EUR"}`, Money{12050, "EUR"}},
+	}
+	for _, tt := range tests {
+		var payment TransactionData
+		if err := json.Unmarshal([]byte(`{"id":"PAY-1","amount":`+tt.amount+`}`), &payment); err != nil {
+			t.Errorf("amount %s: %v", tt.amount, err)
+			continue
+		}
+		if payment.ID != "PAY-1" || payment.Amount != tt.want {
+			t.Errorf("amount %s read as %+v, want %+v", tt.amount, payment.Amount, tt.want)
+		}
+	}
+
+	var payment TransactionData
+	if err := json.Unmarshal([]byte(`{"id":"PAY-1","amount":"100.5"}`), &payment); err == nil {
+		t.Error("a string amount was accepted")
+	}
+}
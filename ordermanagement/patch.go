@@ -0,0 +1,199 @@
+package ordermanagement
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// orderPatch holds the fields of an order PatchOrder may change. The other fields identify
+// the order, are derived from these, are changed by the lifecycle functions only or are
+// private, see OrderPrivateDetails
+type orderPatch struct {
+	Date          string `json:"date,omitempty"`
+	OrderDetail   string `json:"orderDetail,omitempty"`
+	PaymentMethod string `json:"paymentMethod,omitempty"`
+}
+
+// patchableOrderFields are the JSON names of the fields of orderPatch
+var patchableOrderFields = []string{"date", "orderDetail", "paymentMethod"}
+
+// privateOrderFields are the JSON names of the order fields kept in OrderPrivateDetails
+var privateOrderFields = []string{"invoice", "lineItems"}
+
+// decodeJSON decodes JSON keeping numbers as written, so that amounts survive a round trip
+func decodeJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// mergePatch applies a JSON merge patch as defined by RFC 7396 to a decoded JSON value:
+// members of the patch replace those of the target, null members remove them and
+// objects are merged recursively while any other value, arrays included, is replaced
+func mergePatch(target interface{}, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = map[string]interface{}{}
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = mergePatch(targetObject[key], value)
+	}
+	return targetObject
+}
+
+// PatchOrder changes some fields of an order, given as a JSON merge patch (RFC 7396) such
+// as {"orderDetail":"12 pallets"}. Only date, orderDetail and paymentMethod may be
+// patched. The patch is public, so the invoice and line items are changed with UpdateOrder
+// or the line item functions, which take them in the transient map. The patch applies to
+// the version of the order the caller read: it is rejected with a CONFLICT error when the
+// order changed since, so that concurrent updates are not lost
+func (s *SmartContract) PatchOrder(ctx contractapi.TransactionContextInterface, orderNo string, patchJSON string, expectedVersion int) error {
+	// Record the transaction timestamp
+	timestamp, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	logger := newLogger(ctx)
+
+	// Validate the arguments
+	err = validate(ctx,
+		idArg("orderNo", orderNo),
+		arg("patch", patchJSON, required, maxLength(maxTextLength*64)),
+		intArg("expectedVersion", expectedVersion, atLeast(0)),
+	)
+	if err != nil {
+		return err
+	}
+
+	decoded, err := decodeJSON([]byte(patchJSON))
+	if err != nil {
+		return invalidArgument("the patch is not valid JSON: %v", err)
+	}
+	patch, ok := decoded.(map[string]interface{})
+	if !ok || len(patch) == 0 {
+		return invalidArgument("the patch must be a JSON object with at least one field")
+	}
+	fields := make([]string, 0, len(patch))
+	for field := range patch {
+		if contains(privateOrderFields, field) {
+			return newError(CodeInvalidArgument, map[string]string{"field": field},
+				"the %s of an order is private, pass it to UpdateOrder in the transient map under %q", field, privateTransientKey)
+		}
+		if !contains(patchableOrderFields, field) {
+			return newError(CodeInvalidArgument, map[string]string{"field": field},
+				"the field %s of an order cannot be patched, only %v can", field, patchableOrderFields)
+		}
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	// Log the start of the function
+	logger.Debug("Patching order", "orderNo", orderNo, "fields", fields, "expectedVersion", expectedVersion)
+
+	// Retrieve existing order
+	order, err := s.ReadOrder(ctx, orderNo)
+	if err != nil {
+		return err
+	}
+	if order.Version != expectedVersion {
+		return newError(CodeConflict, map[string]string{
+			"type":            orderObjectType,
+			"id":              orderNo,
+			"expectedVersion": strconv.Itoa(expectedVersion),
+			"version":         strconv.Itoa(order.Version),
+		}, "the order %s is at version %d, not %d, read it again before patching", orderNo, order.Version, expectedVersion)
+	}
+
+	// Lifecycle status can only be changed through the transition functions
+	if status := order.lifecycleStatus(); isFinal(status) {
+		return finalStatus(orderObjectType, orderNo, string(status))
+	}
+
+	// Apply the patch to the patchable fields of the order
+	currentJSON, err := json.Marshal(orderPatch{
+		Date:          order.Date,
+		OrderDetail:   order.OrderDetail,
+		PaymentMethod: order.PaymentMethod,
+	})
+	if err != nil {
+		return err
+	}
+	current, err := decodeJSON(currentJSON)
+	if err != nil {
+		return internalError("failed to decode order %s: %v", orderNo, err)
+	}
+	patchedJSON, err := json.Marshal(mergePatch(current, patch))
+	if err != nil {
+		return err
+	}
+	var patched orderPatch
+	if err := json.Unmarshal(patchedJSON, &patched); err != nil {
+		return invalidArgument("invalid patch of order %s: %v", orderNo, err)
+	}
+
+	// The patched order must be valid as a whole
+	methods, err := paymentMethods(ctx)
+	if err != nil {
+		return err
+	}
+	err = validateOrder(ctx, methods, patched.Date, patched.OrderDetail, patched.PaymentMethod)
+	if err != nil {
+		return err
+	}
+
+	order.Date = patched.Date
+	order.OrderDetail = patched.OrderDetail
+	order.PaymentMethod = patched.PaymentMethod
+	order.DocType = orderObjectType
+	order.UpdatedAt = timestamp
+	order.Version++
+
+	// Marshal patched order object to JSON
+	orderJSON, err := json.Marshal(order)
+	if err != nil {
+		return err
+	}
+
+	// Update order in ledger
+	key, err := assetKey(ctx, orderObjectType, orderNo)
+	if err != nil {
+		return err
+	}
+
+	previousJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return internalError("failed to read order %s from world state: %v", orderNo, err)
+	}
+
+	err = ctx.GetStub().PutState(key, orderJSON)
+	if err != nil {
+		return internalError("failed to update order %s in world state: %v", orderNo, err)
+	}
+
+	// Publish order event
+	err = recordMutation(ctx, EventOrderUpdated, orderObjectType, orderNo, previousJSON, orderJSON)
+	if err != nil {
+		return err
+	}
+
+	// Log the success of the operation
+	logger.Info("Order patched", "orderNo", orderNo, "fields", fields, "version", order.Version)
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package ordermanagement
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// patchOrder patches an order as a seller
+func (e *testEnv) patchOrder(orderNo string, patch string, expectedVersion int) error {
+	return e.submit(RoleSeller, "PatchOrder", func(ctx contractapi.TransactionContextInterface) error {
+		return e.contract.PatchOrder(ctx, orderNo, patch, expectedVersion)
+	})
+}
+
+func TestOrderVersion(t *testing.T) {
+	env := newTestEnv(t)
+	env.createOrder("ORD-1")
+	if version := env.readOrder("ORD-1").Version; version != 1 {
+		t.Errorf("version after CreateOrder = %d, want 1", version)
+	}
+
+	env.mustSubmit(RoleSeller, "UpdateOrder", func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.UpdateOrder(ctx, "ORD-1", "2024-03-02", "12 pallets", "ACH")
+	})
+	env.mustSubmit(RoleSeller, "ConfirmOrder", func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.ConfirmOrder(ctx, "ORD-1")
+	})
+	if version := env.readOrder("ORD-1").Version; version != 3 {
+		t.Errorf("version after an update and a transition = %d, want 3", version)
+	}
+}
+
+func TestPatchOrder(t *testing.T) {
+	env := newTestEnv(t)
+	env.createOrder("ORD-1")
+
+	// Two parties read version 1, the second patch is based on a stale read
+	if err := env.patchOrder("ORD-1", `{"orderDetail":"12 pallets"}`, 1); err != nil {
+		t.Fatal(err)
+	}
+	err := env.patchOrder("ORD-1", `{"paymentMethod":"ACH"}`, 1)
+	decoded := decodeError(t, err)
+	wantDetails := map[string]string{"type": "order", "id": "ORD-1", "expectedVersion": "1", "version": "2"}
+	if decoded.Code != CodeConflict || !reflect.DeepEqual(decoded.Details, wantDetails) {
+		t.Errorf("stale patch: got %+v, want a CONFLICT error", decoded)
+	}
+
+	// After reading the order again the second patch merges with the first
+	if err := env.patchOrder("ORD-1", `{"paymentMethod":"ACH"}`, 2); err != nil {
+		t.Fatal(err)
+	}
+	order := env.readOrder("ORD-1")
+	if order.Version != 3 || order.OrderDetail != "12 pallets" || order.PaymentMethod != "ACH" || order.Date != "2024-03-01" {
+		t.Errorf("unexpected patched order: %+v", order)
+	}
+	if event := env.lastEvent(); event.Name != EventOrderUpdated {
+		t.Errorf("event = %s, want %s", event.Name, EventOrderUpdated)
+	}
+}
+
+func TestPatchOrderErrors(t *testing.T) {
+	env := newTestEnv(t)
+	env.createOrder("ORD-1")
+
+	tests := []struct {
+		name    string
+		orderNo string
+		patch   string
+		code    ErrorCode
+	}{
+		{"missing order", "ORD-2", `{"orderDetail":"x"}`, CodeNotFound},
+		{"not JSON", "ORD-1", `{"orderDetail":`, CodeInvalidArgument},
+		{"not an object", "ORD-1", `["orderDetail"]`, CodeInvalidArgument},
+		{"empty patch", "ORD-1", `{}`, CodeInvalidArgument},
+		{"immutable field", "ORD-1", `{"status":"DELIVERED"}`, CodeInvalidArgument},
+		{"unknown field", "ORD-1", `{"colour":"red"}`, CodeInvalidArgument},
+		{"required field cleared", "ORD-1", `{"paymentMethod":null}`, CodeInvalidArgument},
+		{"invalid field", "ORD-1", `{"date":"01/03/2024"}`, CodeInvalidArgument},
+		{"private invoice", "ORD-1", `{"invoice":"INV-2"}`, CodeInvalidArgument},
+		{"private line items", "ORD-1", `{"lineItems":[]}`, CodeInvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := env.patchOrder(tt.orderNo, tt.patch, 1)
+			if code := decodeError(t, err).Code; code != tt.code {
+				t.Errorf("code = %s, want %s", code, tt.code)
+			}
+		})
+	}
+
+	if order := env.readOrder("ORD-1"); order.Version != 1 {
+		t.Errorf("a rejected patch changed the order to version %d", order.Version)
+	}
+}
@@ -0,0 +1,268 @@
+package ordermanagement
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// PaymentStatus represents a step of the payment lifecycle
+type PaymentStatus string
+
+const (
+	PaymentPending           PaymentStatus = "Pending"
+	PaymentAuthorized        PaymentStatus = "Authorized"
+	PaymentCaptured          PaymentStatus = "Captured"
+	PaymentSettled           PaymentStatus = "Settled"
+	PaymentPartiallyRefunded PaymentStatus = "PartiallyRefunded"
+	PaymentRefunded          PaymentStatus = "Refunded"
+	PaymentChargedBack       PaymentStatus = "ChargedBack"
+	PaymentFailed            PaymentStatus = "Failed"
+)
+
+// paymentTransitions lists, for each status, the statuses a payment may move to next
+var paymentTransitions = map[PaymentStatus][]PaymentStatus{
+	PaymentPending:           {PaymentAuthorized, PaymentFailed},
+	PaymentAuthorized:        {PaymentCaptured, PaymentFailed},
+	PaymentCaptured:          {PaymentSettled, PaymentPartiallyRefunded, PaymentRefunded, PaymentChargedBack},
+	PaymentSettled:           {PaymentPartiallyRefunded, PaymentRefunded, PaymentChargedBack},
+	PaymentPartiallyRefunded: {PaymentPartiallyRefunded, PaymentRefunded, PaymentChargedBack},
+	PaymentRefunded:          {},
+	PaymentChargedBack:       {},
+	PaymentFailed:            {},
+}
+
+// PaymentTransition records a single lifecycle step of a payment and who performed it.
+// Amount is the captured or refunded amount for captures and refunds
+type PaymentTransition struct {
+	From      PaymentStatus `json:"from"`
+	To        PaymentStatus `json:"to"`
+	Amount    *Money        `json:"amount,omitempty" metadata:",optional"`
+	Actor     string        `json:"actor"`
+	MSPID     string        `json:"mspId"`
+	TxID      string        `json:"txId"`
+	Reason    string        `json:"reason,omitempty" metadata:",optional"`
+	Timestamp string        `json:"timestamp"`
+}
+
+// canTransitionPayment reports whether a payment may move from one status to another
+func canTransitionPayment(from, to PaymentStatus) bool {
+	for _, next := range paymentTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// UnmarshalJSON decodes a payment. Payments written before amounts were exact hold a
+// bare number of US dollars, which is only accepted here, as the amount of a stored payment
+func (t *TransactionData) UnmarshalJSON(data []byte) error {
+	type transactionData TransactionData
+	var decoded struct {
+		transactionData
+		Amount json.RawMessage `json:"amount"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*t = TransactionData(decoded.transactionData)
+	if len(decoded.Amount) == 0 || string(decoded.Amount) == "null" {
+		return nil
+	}
+
+	var legacy float64
+	if err := json.Unmarshal(decoded.Amount, &legacy); err == nil {
+		t.Amount, err = legacyMoney(legacy)
+		return err
+	}
+	return json.Unmarshal(decoded.Amount, &t.Amount)
+}
+
+// paymentStatus returns the current status of the payment. Payments recorded before
+// the lifecycle was introduced were final entries and are treated as captured in full
+func (t *TransactionData) paymentStatus() PaymentStatus {
+	if t.Status != "" {
+		return t.Status
+	}
+	return PaymentCaptured
+}
+
+// capturedAmount returns the captured amount of the payment
+func (t *TransactionData) capturedAmount() Money {
+	if t.CapturedAmount != nil {
+		return *t.CapturedAmount
+	}
+	if t.Status == "" {
+		return t.Amount
+	}
+	return Money{Currency: t.Amount.Currency}
+}
+
+// refundedAmount returns the amount refunded so far
+func (t *TransactionData) refundedAmount() Money {
+	if t.RefundedAmount != nil {
+		return *t.RefundedAmount
+	}
+	return Money{Currency: t.Amount.Currency}
+}
+
+// AuthorizePayment records the authorization of a pending payment
+func (s *SmartContract) AuthorizePayment(ctx contractapi.TransactionContextInterface, id string) error {
+	return s.transitionPayment(ctx, id, PaymentAuthorized, "", "")
+}
+
+// CapturePayment captures an authorized payment. The amount is a decimal string in
+// the currency of the payment and may be lower than the authorized amount, an empty
+// amount captures the authorized amount in full
+func (s *SmartContract) CapturePayment(ctx contractapi.TransactionContextInterface, id string, amount string) error {
+	return s.transitionPayment(ctx, id, PaymentCaptured, amount, "")
+}
+
+// SettlePayment records the settlement of a captured payment
+func (s *SmartContract) SettlePayment(ctx contractapi.TransactionContextInterface, id string) error {
+	return s.transitionPayment(ctx, id, PaymentSettled, "", "")
+}
+
+// RefundPayment refunds part or all of a captured payment. The amount is a decimal
+// string in the currency of the payment, the refunds of a payment can never exceed
+// its captured amount
+func (s *SmartContract) RefundPayment(ctx contractapi.TransactionContextInterface, id string, amount string, reason string) error {
+	return s.transitionPayment(ctx, id, PaymentRefunded, amount, reason)
+}
+
+// RecordChargeback records a chargeback of a captured payment
+func (s *SmartContract) RecordChargeback(ctx contractapi.TransactionContextInterface, id string, reason string) error {
+	return s.transitionPayment(ctx, id, PaymentChargedBack, "", reason)
+}
+
+// FailPayment records the failure of a payment that has not been captured
+func (s *SmartContract) FailPayment(ctx contractapi.TransactionContextInterface, id string, reason string) error {
+	return s.transitionPayment(ctx, id, PaymentFailed, "", reason)
+}
+
+// transitionPayment moves a payment to the given status if the lifecycle allows it and
+// records the step together with the identity of the caller. Refunds move the payment
+// to Refunded once the captured amount is refunded in full and to PartiallyRefunded before
+func (s *SmartContract) transitionPayment(ctx contractapi.TransactionContextInterface, id string, to PaymentStatus, amount string, reason string) error {
+	// Record the transaction timestamp
+	timestamp, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	logger := newLogger(ctx)
+
+	// Log the start of the function
+	logger.Debug("Moving payment", "id", id, "to", to, "amount", amount, "reason", reason)
+
+	// Retrieve transaction ID and caller ID
+	txID := ctx.GetStub().GetTxID()
+	callerID, _ := ctx.GetClientIdentity().GetID()
+	mspID, _ := ctx.GetClientIdentity().GetMSPID()
+
+	// Validate the arguments
+	err = validate(ctx,
+		idArg("id", id),
+		arg("amount", amount, matches(decimalPattern, "a decimal amount such as 100.50")),
+		textArg("reason", reason),
+	)
+	if err != nil {
+		return err
+	}
+
+	payment, err := s.GetTransaction(ctx, id)
+	if err != nil {
+		return err
+	}
+	from := payment.paymentStatus()
+
+	// Refunds are checked as full refunds, every status that allows one also allows a partial refund
+	if !canTransitionPayment(from, to) {
+		return invalidTransition(paymentObjectType, id, string(from), string(to))
+	}
+
+	var stepAmount *Money
+	switch to {
+	case PaymentCaptured:
+		captured := payment.Amount
+		if amount != "" {
+			captured, err = ParseMoney(amount, payment.Amount.Currency)
+			if err != nil {
+				return wrapError(err, CodeInvalidArgument, "invalid capture amount for payment %s", id)
+			}
+		}
+		if captured.Amount <= 0 || captured.Amount > payment.Amount.Amount {
+			return invalidArgument("the capture of payment %s must be positive and at most %s, got %s", id, payment.Amount, captured)
+		}
+		payment.CapturedAmount = &captured
+		stepAmount = &captured
+
+	case PaymentRefunded:
+		refund, err := ParseMoney(amount, payment.Amount.Currency)
+		if err != nil {
+			return wrapError(err, CodeInvalidArgument, "invalid refund amount for payment %s", id)
+		}
+		if refund.Amount <= 0 {
+			return invalidArgument("the refund of payment %s must be positive, got %s", id, refund)
+		}
+
+		refunded, err := payment.refundedAmount().Add(refund)
+		if err != nil {
+			return wrapError(err, CodeInvalidArgument, "invalid refund of payment %s", id)
+		}
+		captured := payment.capturedAmount()
+		if refunded.Amount > captured.Amount {
+			return invalidArgument("refunding %s would bring the refunds of payment %s to %s, more than the captured %s",
+				refund, id, refunded, captured)
+		}
+		if refunded.Amount < captured.Amount {
+			to = PaymentPartiallyRefunded
+		}
+		payment.RefundedAmount = &refunded
+		stepAmount = &refund
+	}
+
+	payment.Status = to
+	payment.UpdatedAt = timestamp
+	payment.Transitions = append(payment.Transitions, PaymentTransition{
+		From:      from,
+		To:        to,
+		Amount:    stepAmount,
+		Actor:     callerID,
+		MSPID:     mspID,
+		TxID:      txID,
+		Reason:    reason,
+		Timestamp: timestamp,
+	})
+
+	dataBytes, err := json.Marshal(payment)
+	if err != nil {
+		return err
+	}
+
+	key, err := assetKey(ctx, paymentObjectType, id)
+	if err != nil {
+		return err
+	}
+
+	previousBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return internalError("failed to read payment %s from world state: %v", id, err)
+	}
+
+	err = ctx.GetStub().PutState(key, dataBytes)
+	if err != nil {
+		return internalError("failed to update payment %s in world state: %v", id, err)
+	}
+
+	// Publish payment event
+	err = recordMutation(ctx, EventPaymentStatusChanged, paymentObjectType, id, previousBytes, dataBytes)
+	if err != nil {
+		return err
+	}
+
+	// Log the success of the operation
+	logger.Info("Payment moved", "id", id, "from", from, "to", to)
+
+	return nil
+}
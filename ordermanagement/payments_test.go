@@ -0,0 +1,247 @@
+package ordermanagement
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// readPayment reads a payment as an auditor and fails the test if it does not exist
+func (e *testEnv) readPayment(id string) *TransactionData {
+	e.t.Helper()
+	var payment *TransactionData
+	e.mustSubmit(RoleAuditor, "GetTransaction", func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		payment, err = e.contract.GetTransaction(ctx, id)
+		return err
+	})
+	return payment
+}
+
+// paymentStep runs a payment lifecycle function as a bank
+func (e *testEnv) paymentStep(function string, fn func(ctx contractapi.TransactionContextInterface) error) error {
+	return e.submit(RoleBank, function, fn)
+}
+
+// usd returns an amount in dollar cents
+func usd(cents int64) *Money {
+	return &Money{Amount: cents, Currency: "USD"}
+}
+
+func TestPaymentLifecycle(t *testing.T) {
+	env := newTestEnv(t)
+	env.createOrder("ORD-1")
+	env.createPayment("PAY-1", "ORD-1")
+
+	if status := env.readPayment("PAY-1").Status; status != PaymentPending {
+		t.Fatalf("new payment is %s, want %s", status, PaymentPending)
+	}
+
+	steps := []struct {
+		function string
+		call     func(ctx contractapi.TransactionContextInterface) error
+		status   PaymentStatus
+		captured *Money
+		refunded *Money
+	}{
+		{"AuthorizePayment", func(ctx contractapi.TransactionContextInterface) error {
+			return env.contract.AuthorizePayment(ctx, "PAY-1")
+		}, PaymentAuthorized, nil, nil},
+		{"CapturePayment", func(ctx contractapi.TransactionContextInterface) error {
+			return env.contract.CapturePayment(ctx, "PAY-1", "80.00")
+		}, PaymentCaptured, usd(8000), nil},
+		{"SettlePayment", func(ctx contractapi.TransactionContextInterface) error {
+			return env.contract.SettlePayment(ctx, "PAY-1")
+		}, PaymentSettled, usd(8000), nil},
+		{"RefundPayment", func(ctx contractapi.TransactionContextInterface) error {
+			return env.contract.RefundPayment(ctx, "PAY-1", "30.00", "one pallet damaged")
+		}, PaymentPartiallyRefunded, usd(8000), usd(3000)},
+		{"RefundPayment", func(ctx contractapi.TransactionContextInterface) error {
+			return env.contract.RefundPayment(ctx, "PAY-1", "50.00", "order returned")
+		}, PaymentRefunded, usd(8000), usd(8000)},
+	}
+
+	for i, step := range steps {
+		if err := env.paymentStep(step.function, step.call); err != nil {
+			t.Fatalf("step %d %s: %v", i, step.function, err)
+		}
+		payment := env.readPayment("PAY-1")
+		if payment.Status != step.status {
+			t.Errorf("after %s the payment is %s, want %s", step.function, payment.Status, step.status)
+		}
+		if (payment.CapturedAmount == nil) != (step.captured == nil) || (step.captured != nil && *payment.CapturedAmount != *step.captured) {
+			t.Errorf("after %s the captured amount is %v, want %v", step.function, payment.CapturedAmount, step.captured)
+		}
+		if (payment.RefundedAmount == nil) != (step.refunded == nil) || (step.refunded != nil && *payment.RefundedAmount != *step.refunded) {
+			t.Errorf("after %s the refunded amount is %v, want %v", step.function, payment.RefundedAmount, step.refunded)
+		}
+		if payment.Amount != *usd(10000) {
+			t.Errorf("after %s the payment amount changed to %s", step.function, payment.Amount)
+		}
+	}
+
+	payment := env.readPayment("PAY-1")
+	if len(payment.Transitions) != len(steps) {
+		t.Fatalf("got %d transitions, want %d", len(payment.Transitions), len(steps))
+	}
+	refund := payment.Transitions[3]
+	if refund.From != PaymentSettled || refund.To != PaymentPartiallyRefunded || refund.Reason != "one pallet damaged" ||
+		refund.Amount == nil || *refund.Amount != *usd(3000) {
+		t.Errorf("unexpected refund transition: %+v", refund)
+	}
+	if refund.Actor != testIdentity(RoleBank).ID || refund.MSPID != testMSPID(RoleBank) || refund.TxID == "" || refund.Timestamp == "" {
+		t.Errorf("refund transition does not record the caller: %+v", refund)
+	}
+
+	event := env.lastEvent()
+	if event.Name != EventPaymentStatusChanged {
+		t.Errorf("last event is %s, want %s", event.Name, EventPaymentStatusChanged)
+	}
+
+	entries := env.auditTrail("payment:PAY-1")
+	if len(entries) != 1+len(steps) {
+		t.Fatalf("got %d audit entries, want %d", len(entries), 1+len(steps))
+	}
+	for _, entry := range entries[1:] {
+		if entry.Action != EventPaymentStatusChanged {
+			t.Errorf("audit entry %d is %s, want %s", entry.Sequence, entry.Action, EventPaymentStatusChanged)
+		}
+	}
+	if result := env.verifyAudit("payment:PAY-1"); !result.Valid {
+		t.Errorf("audit chain of the payment is broken: %+v", result)
+	}
+}
+
+func TestCaptureInFull(t *testing.T) {
+	env := newTestEnv(t)
+	env.createOrder("ORD-1")
+	env.createPayment("PAY-1", "ORD-1")
+
+	env.mustSubmit(RoleBank, "AuthorizePayment", func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.AuthorizePayment(ctx, "PAY-1")
+	})
+	env.mustSubmit(RoleBank, "CapturePayment", func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.CapturePayment(ctx, "PAY-1", "")
+	})
+	if captured := env.readPayment("PAY-1").CapturedAmount; captured == nil || *captured != *usd(10000) {
+		t.Errorf("captured amount is %v, want 100.00 USD", captured)
+	}
+
+	env.mustSubmit(RoleBank, "RecordChargeback", func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.RecordChargeback(ctx, "PAY-1", "disputed by card holder")
+	})
+	if status := env.readPayment("PAY-1").Status; status != PaymentChargedBack {
+		t.Errorf("payment is %s after a chargeback", status)
+	}
+}
+
+func TestIllegalPaymentTransitions(t *testing.T) {
+	env := newTestEnv(t)
+	env.createOrder("ORD-1")
+	env.createPayment("PAY-1", "ORD-1")
+
+	capture := func(amount string) func(ctx contractapi.TransactionContextInterface) error {
+		return func(ctx contractapi.TransactionContextInterface) error {
+			return env.contract.CapturePayment(ctx, "PAY-1", amount)
+		}
+	}
+	refund := func(amount string) func(ctx contractapi.TransactionContextInterface) error {
+		return func(ctx contractapi.TransactionContextInterface) error {
+			return env.contract.RefundPayment(ctx, "PAY-1", amount, "")
+		}
+	}
+
+	// Nothing can be captured or refunded before the authorization
+	if err := env.paymentStep("CapturePayment", capture("")); err == nil {
+		t.Error("a pending payment was captured")
+	}
+	if err := env.paymentStep("RefundPayment", refund("10.00")); err == nil {
+		t.Error("a pending payment was refunded")
+	}
+
+	env.mustSubmit(RoleBank, "AuthorizePayment", func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.AuthorizePayment(ctx, "PAY-1")
+	})
+	for _, amount := range []string{"100.01", "0", "-5.00", "1.005", "ten"} {
+		if err := env.paymentStep("CapturePayment", capture(amount)); err == nil {
+			t.Errorf("capture of %s was accepted", amount)
+		}
+	}
+	env.mustSubmit(RoleBank, "CapturePayment", capture("60.00"))
+
+	if err := env.paymentStep("FailPayment", func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.FailPayment(ctx, "PAY-1", "timeout")
+	}); err == nil {
+		t.Error("a captured payment was failed")
+	}
+	if err := env.paymentStep("RefundPayment", refund("60.01")); err == nil {
+		t.Error("a refund above the captured amount was accepted")
+	}
+	env.mustSubmit(RoleBank, "RefundPayment", refund("60.00"))
+	if err := env.paymentStep("RefundPayment", refund("0.01")); err == nil {
+		t.Error("a refunded payment was refunded again")
+	}
+
+	payment := env.readPayment("PAY-1")
+	if payment.Status != PaymentRefunded || len(payment.Transitions) != 3 {
+		t.Errorf("rejected steps changed the payment: %+v", payment)
+	}
+}
+
+func TestPaymentLifecycleAccess(t *testing.T) {
+	env := newTestEnv(t)
+	env.createOrder("ORD-1")
+	env.createPayment("PAY-1", "ORD-1")
+
+	for _, role := range []string{RoleBuyer, RoleSeller, RoleCarrier, RoleAuditor} {
+		err := env.submit(role, "AuthorizePayment", func(ctx contractapi.TransactionContextInterface) error {
+			return env.contract.AuthorizePayment(ctx, "PAY-1")
+		})
+		if err == nil {
+			t.Errorf("%s authorized a payment", role)
+		}
+	}
+	if err := env.paymentStep("AuthorizePayment", func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.AuthorizePayment(ctx, "PAY-2")
+	}); err == nil {
+		t.Error("a missing payment was authorized")
+	}
+}
+
+func TestLegacyPaymentStatus(t *testing.T) {
+	env := newTestEnv(t)
+	key, _ := env.stub.CreateCompositeKey(paymentObjectType, []string{"PAY-1"})
+	env.stub.PutCommittedState(key, []byte(`{"id":"PAY-1","orderNo":"ORD-1","type":"ACH","amount":{"amount":10000,"currency":"USD"},"account":"1234567890"}`))
+
+	if err := env.paymentStep("AuthorizePayment", func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.AuthorizePayment(ctx, "PAY-1")
+	}); err == nil {
+		t.Error("a payment recorded before the lifecycle was authorized")
+	}
+	env.mustSubmit(RoleBank, "RefundPayment", func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.RefundPayment(ctx, "PAY-1", "100.00", "duplicate payment")
+	})
+
+	payment := env.readPayment("PAY-1")
+	if payment.Status != PaymentRefunded || payment.Transitions[0].From != PaymentCaptured {
+		t.Errorf("legacy payment was not refunded as captured: %+v", payment)
+	}
+}
+
+func TestRefundMigratedPayment(t *testing.T) {
+	env := newTestEnv(t)
+	env.stub.PutCommittedState("PAY-1", []byte(`{"id":"PAY-1","type":"ACH","amount":100.5,"account":"1234567890"}`))
+	env.mustSubmit(RoleAdmin, "MigrateToCompositeKeys", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.contract.MigrateToCompositeKeys(ctx)
+		return err
+	})
+
+	env.mustSubmit(RoleBank, "RefundPayment", func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.RefundPayment(ctx, "PAY-1", "40.25", "damaged goods")
+	})
+
+	payment := env.readPayment("PAY-1")
+	if payment.Status != PaymentPartiallyRefunded || *payment.RefundedAmount != (Money{4025, "USD"}) {
+		t.Errorf("migrated payment was not partially refunded: %+v", payment)
+	}
+}
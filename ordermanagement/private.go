@@ -0,0 +1,327 @@
+package ordermanagement
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Private data collections declared in collections_config.json
+const (
+	orderPrivateCollection   = "orderPrivateDetails"
+	paymentPrivateCollection = "paymentPrivateDetails"
+)
+
+// privateTransientKey is the key of the transient map entry carrying the private details
+// of an order or payment, which never reach the blocks or the public world state
+const privateTransientKey = "private"
+
+// minSaltLength is the minimum length of the salt of the private details. The salt keeps
+// the public hash from being brute forced from guessable values such as invoice numbers
+const minSaltLength = 16
+
+// OrderPrivateDetails holds the commercially sensitive part of an order: the invoice and
+// the priced line items. They are kept in the orderPrivateDetails collection and only
+// their salted hash is written to the order in the public world state
+type OrderPrivateDetails struct {
+	OrderNo   string     `json:"orderNo"`
+	Invoice   string     `json:"invoice"`
+	LineItems []LineItem `json:"lineItems,omitempty" metadata:",optional"`
+	Subtotal  *Money     `json:"subtotal,omitempty" metadata:",optional"`
+	Tax       *Money     `json:"tax,omitempty" metadata:",optional"`
+	Total     *Money     `json:"total,omitempty" metadata:",optional"`
+	Salt      string     `json:"salt"`
+}
+
+// PaymentPrivateDetails holds the account of a payment, kept in the paymentPrivateDetails
+// collection with only its salted hash written to the payment in the public world state
+type PaymentPrivateDetails struct {
+	ID      string `json:"id"`
+	Account string `json:"account"`
+	Salt    string `json:"salt"`
+}
+
+// privateHash returns the hex encoded SHA-256 of the JSON of the private details. The salt
+// is part of the JSON, so the hash can only be reproduced by someone holding the details
+func privateHash(details interface{}) (string, error) {
+	detailsJSON, err := json.Marshal(details)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(detailsJSON)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// transientPrivate returns the private details passed in the transient map, or nil when
+// the caller passed none
+func transientPrivate(ctx contractapi.TransactionContextInterface) ([]byte, error) {
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return nil, internalError("failed to read the transient map: %v", err)
+	}
+	return transient[privateTransientKey], nil
+}
+
+// decodePrivate strictly decodes private details so that misspelt fields are rejected
+// rather than silently left out of the hash
+func decodePrivate(data []byte, details interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(details); err != nil {
+		return invalidArgument("invalid private details: %v", err)
+	}
+	return nil
+}
+
+// decodeOrderPrivate decodes the private details of an order and checks the salt. Their
+// line items are left to be priced by the caller
+func decodeOrderPrivate(data []byte, orderNo string) (*OrderPrivateDetails, error) {
+	var details OrderPrivateDetails
+	if err := decodePrivate(data, &details); err != nil {
+		return nil, err
+	}
+	if details.OrderNo != "" && details.OrderNo != orderNo {
+		return nil, invalidArgument("the private details are those of order %s, not %s", details.OrderNo, orderNo)
+	}
+	if len(details.Salt) < minSaltLength {
+		return nil, invalidArgument("the salt of the private details must be at least %d characters long", minSaltLength)
+	}
+	details.OrderNo = orderNo
+	return &details, nil
+}
+
+// setLineItems prices the given line items like Order.setLineItems, numbering those
+// without a line number after the others, and replaces the line items and totals of the
+// private details by them
+func (d *OrderPrivateDetails) setLineItems(items []LineItem) error {
+	priced := Order{LineItems: d.LineItems}
+	if err := priced.setLineItems(items); err != nil {
+		return wrapError(err, CodeInvalidArgument, "invalid line items for order %s", d.OrderNo)
+	}
+	d.LineItems = priced.LineItems
+	d.Subtotal, d.Tax, d.Total = priced.Subtotal, priced.Tax, priced.Total
+	return nil
+}
+
+// decodePaymentPrivate decodes the private details of a payment and checks the salt
+func decodePaymentPrivate(data []byte, id string) (*PaymentPrivateDetails, error) {
+	var details PaymentPrivateDetails
+	if err := decodePrivate(data, &details); err != nil {
+		return nil, err
+	}
+	if details.ID != "" && details.ID != id {
+		return nil, invalidArgument("the private details are those of payment %s, not %s", details.ID, id)
+	}
+	if len(details.Salt) < minSaltLength {
+		return nil, invalidArgument("the salt of the private details must be at least %d characters long", minSaltLength)
+	}
+	details.ID = id
+	return &details, nil
+}
+
+// orderPrivateDetails returns the private details of an order passed in the transient
+// map, or nil when the caller passed none. Their line items are numbered from 1 and
+// priced the same way CreateOrder does
+func orderPrivateDetails(ctx contractapi.TransactionContextInterface, orderNo string) (*OrderPrivateDetails, error) {
+	data, err := transientPrivate(ctx)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	details, err := decodeOrderPrivate(data, orderNo)
+	if err != nil {
+		return nil, err
+	}
+	if err := details.setLineItems(newLineItems(details.LineItems)); err != nil {
+		return nil, err
+	}
+	return details, nil
+}
+
+// paymentPrivateDetails returns the private details of a payment passed in the transient
+// map, or nil when the caller passed none
+func paymentPrivateDetails(ctx contractapi.TransactionContextInterface, id string) (*PaymentPrivateDetails, error) {
+	data, err := transientPrivate(ctx)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	return decodePaymentPrivate(data, id)
+}
+
+// putPrivate writes the private details of an asset to a collection under the key of the
+// asset and returns their hash
+func putPrivate(ctx contractapi.TransactionContextInterface, collection string, objectType string, id string, details interface{}) (string, error) {
+	detailsJSON, err := json.Marshal(details)
+	if err != nil {
+		return "", err
+	}
+
+	key, err := assetKey(ctx, objectType, id)
+	if err != nil {
+		return "", err
+	}
+
+	err = ctx.GetStub().PutPrivateData(collection, key, detailsJSON)
+	if err != nil {
+		return "", internalError("failed to put private details of %s %s to collection %s: %v", objectType, id, collection, err)
+	}
+
+	return privateHash(details)
+}
+
+// readPrivate unmarshals the private details of an asset from a collection into details
+func readPrivate(ctx contractapi.TransactionContextInterface, collection string, objectType string, id string, details interface{}) error {
+	key, err := assetKey(ctx, objectType, id)
+	if err != nil {
+		return err
+	}
+
+	detailsJSON, err := ctx.GetStub().GetPrivateData(collection, key)
+	if err != nil {
+		return internalError("failed to read private details of %s %s from collection %s: %v", objectType, id, collection, err)
+	}
+	if detailsJSON == nil {
+		return newError(CodeNotFound, map[string]string{"type": objectType, "id": id, "collection": collection},
+			"the %s %s has no private details in collection %s", objectType, id, collection)
+	}
+
+	err = json.Unmarshal(detailsJSON, details)
+	if err != nil {
+		return internalError("failed to decode private details of %s %s: %v", objectType, id, err)
+	}
+	return nil
+}
+
+// ReadOrderPrivateDetails returns the invoice and line items of an order kept in the
+// orderPrivateDetails collection. Only peers of the collection members hold them
+func (s *SmartContract) ReadOrderPrivateDetails(ctx contractapi.TransactionContextInterface, orderNo string) (*OrderPrivateDetails, error) {
+	logger := newLogger(ctx)
+
+	// Log parameter details
+	logger.Debug("Reading private details of order", "orderNo", orderNo)
+
+	// Validate the arguments
+	err := validate(ctx,
+		idArg("orderNo", orderNo),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var details OrderPrivateDetails
+	err = readPrivate(ctx, orderPrivateCollection, orderObjectType, orderNo, &details)
+	if err != nil {
+		return nil, err
+	}
+
+	// Log the success of the operation
+	logger.Info("Private details of order read", "orderNo", orderNo)
+
+	return &details, nil
+}
+
+// ReadTransactionPrivateDetails returns the account of a payment kept in the
+// paymentPrivateDetails collection. Only peers of the collection members hold it
+func (s *SmartContract) ReadTransactionPrivateDetails(ctx contractapi.TransactionContextInterface, id string) (*PaymentPrivateDetails, error) {
+	logger := newLogger(ctx)
+
+	// Log parameter details
+	logger.Debug("Reading private details of payment", "id", id)
+
+	// Validate the arguments
+	err := validate(ctx,
+		idArg("id", id),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var details PaymentPrivateDetails
+	err = readPrivate(ctx, paymentPrivateCollection, paymentObjectType, id, &details)
+	if err != nil {
+		return nil, err
+	}
+
+	// Log the success of the operation
+	logger.Info("Private details of payment read", "id", id)
+
+	return &details, nil
+}
+
+// VerifyPrivateHash reports whether the private details passed in the transient map under
+// "private" match the hash recorded for an asset identified as order:<orderNo> or
+// payment:<id>. A counterparty that received the details off-chain can prove they are
+// the recorded ones without being a member of the collection and without revealing them
+func (s *SmartContract) VerifyPrivateHash(ctx contractapi.TransactionContextInterface, asset string) (bool, error) {
+	logger := newLogger(ctx)
+
+	// Log parameter details
+	logger.Debug("Verifying private details", "asset", asset)
+
+	// Validate the arguments
+	err := validate(ctx,
+		arg("asset", asset, required),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	objectType, id, err := parseAssetKey(asset)
+	if err != nil {
+		return false, err
+	}
+
+	data, err := transientPrivate(ctx)
+	if err != nil {
+		return false, err
+	}
+	if data == nil {
+		return false, invalidArgument("pass the private details to verify in the transient map under %q", privateTransientKey)
+	}
+
+	var recorded string
+	var claimed interface{}
+	switch objectType {
+	case orderObjectType:
+		var order Order
+		if err := readLinked(ctx, orderObjectType, id, &order); err != nil {
+			return false, err
+		}
+		recorded = order.PrivateHash
+		// The line items keep their numbers, which need not run from 1 once an item was
+		// removed, so the details returned by ReadOrderPrivateDetails match as they are
+		var details *OrderPrivateDetails
+		details, err = decodeOrderPrivate(data, id)
+		if err == nil {
+			err = details.setLineItems(details.LineItems)
+		}
+		claimed = details
+	case paymentObjectType:
+		var payment TransactionData
+		if err := readLinked(ctx, paymentObjectType, id, &payment); err != nil {
+			return false, err
+		}
+		recorded = payment.PrivateHash
+		claimed, err = decodePaymentPrivate(data, id)
+	default:
+		return false, invalidArgument("the asset type %s has no private details", objectType)
+	}
+	if err != nil {
+		return false, err
+	}
+	if recorded == "" {
+		return false, newError(CodeNotFound, map[string]string{"type": objectType, "id": id}, "the %s %s keeps no private details", objectType, id)
+	}
+
+	hash, err := privateHash(claimed)
+	if err != nil {
+		return false, err
+	}
+
+	// Log the success of the operation
+	logger.Info("Private details verified", "asset", asset, "match", hash == recorded)
+
+	return hash == recorded, nil
+}
@@ -0,0 +1,302 @@
+package ordermanagement
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// testSalt is a salt long enough for the private details
+const testSalt = "0123456789abcdef"
+
+// submitPrivate is like submit but passes the private details in the transient map
+func (e *testEnv) submitPrivate(role string, function string, private string, fn func(ctx contractapi.TransactionContextInterface) error) error {
+	return e.submit(role, function, func(ctx contractapi.TransactionContextInterface) error {
+		e.stub.SetTransient(map[string][]byte{privateTransientKey: []byte(private)})
+		return fn(ctx)
+	})
+}
+
+// createPrivateOrder creates an order keeping its invoice and line items private
+func (e *testEnv) createPrivateOrder(orderNo string, private string) error {
+	return e.submitPrivate(RoleSeller, "CreateOrder", private, func(ctx contractapi.TransactionContextInterface) error {
+		return e.contract.CreateOrder(ctx, orderNo, "2024-03-01", "10 pallets", "CreditCard")
+	})
+}
+
+// verifyPrivate checks private details against the hash recorded for an asset as an auditor
+func (e *testEnv) verifyPrivate(asset string, private string) (bool, error) {
+	var match bool
+	err := e.submitPrivate(RoleAuditor, "VerifyPrivateHash", private, func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		match, err = e.contract.VerifyPrivateHash(ctx, asset)
+		return err
+	})
+	return match, err
+}
+
+// paymentPrivate and orderPrivate are sample private details of a payment and an order
+const paymentPrivate = `{"account":"1234567890","salt":"` + testSalt + `"}`
+
+const orderPrivate = `{"invoice":"INV-SECRET-1","lineItems":[{"sku":"PAL-1","description":"Pallet","quantity":4,"unit":"pcs","unitPrice":{"amount":2500,"currency":"EUR"},"taxRate":20}],"salt":"` + testSalt + `"}`
+
+func TestCreatePrivateOrder(t *testing.T) {
+	env := newTestEnv(t)
+	if err := env.createPrivateOrder("ORD-1", orderPrivate); err != nil {
+		t.Fatal(err)
+	}
+
+	order := env.readOrder("ORD-1")
+	if order.Invoice != "" || order.LineItems != nil || order.Total != nil || len(order.PrivateHash) != 64 {
+		t.Errorf("private details leaked into the public order: %+v", order)
+	}
+	if publicJSON := env.stub.CommittedState(orderKey(t, env, "ORD-1")); bytes.Contains(publicJSON, []byte("INV-SECRET-1")) {
+		t.Errorf("the invoice is in the public world state: %s", publicJSON)
+	}
+
+	var details *OrderPrivateDetails
+	env.mustSubmit(RoleBuyer, "ReadOrderPrivateDetails", func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		details, err = env.contract.ReadOrderPrivateDetails(ctx, "ORD-1")
+		return err
+	})
+	if details.OrderNo != "ORD-1" || details.Invoice != "INV-SECRET-1" || len(details.LineItems) != 1 || details.LineItems[0].LineNo != 1 {
+		t.Errorf("unexpected private details: %+v", details)
+	}
+	if details.Total == nil || *details.Total != *eur(12000) {
+		t.Errorf("private line items were not priced: %+v", details.Total)
+	}
+
+	hash, err := privateHash(details)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != order.PrivateHash {
+		t.Errorf("recorded hash %s is not the hash of the private details %s", order.PrivateHash, hash)
+	}
+}
+
+func TestCreatePrivateOrderErrors(t *testing.T) {
+	env := newTestEnv(t)
+
+	invalid := []string{
+		`{"invoice":"INV-1","salt":"short"}`,
+		`{"invoice":"INV-1","invoiceNo":"INV-1","salt":"` + testSalt + `"}`,
+		`{"orderNo":"ORD-2","invoice":"INV-1","salt":"` + testSalt + `"}`,
+		`{"invoice":"INV-1","lineItems":[{"sku":"PAL-1","quantity":0,"unit":"pcs","unitPrice":{"amount":1,"currency":"EUR"}}],"salt":"` + testSalt + `"}`,
+		`not json`,
+	}
+	for i, private := range invalid {
+		if err := env.createPrivateOrder("ORD-1", private); err == nil {
+			t.Errorf("case %d: invalid private details %s accepted", i, private)
+		}
+	}
+}
+
+func TestVerifyPrivateHash(t *testing.T) {
+	env := newTestEnv(t)
+	if err := env.createPrivateOrder("ORD-1", orderPrivate); err != nil {
+		t.Fatal(err)
+	}
+	env.createOrder("ORD-2")
+
+	tests := []struct {
+		private string
+		match   bool
+	}{
+		{orderPrivate, true},
+		// Computed fields and line numbers may be left out, they are derived again
+		{strings.Replace(orderPrivate, `"sku"`, `"lineNo":1,"sku"`, 1), true},
+		{strings.Replace(orderPrivate, "INV-SECRET-1", "INV-SECRET-2", 1), false},
+		{strings.Replace(orderPrivate, `"quantity":4`, `"quantity":5`, 1), false},
+		{strings.Replace(orderPrivate, testSalt, "fedcba9876543210", 1), false},
+	}
+	for i, tt := range tests {
+		match, err := env.verifyPrivate("order:ORD-1", tt.private)
+		if err != nil {
+			t.Fatalf("case %d: %v", i, err)
+		}
+		if match != tt.match {
+			t.Errorf("case %d: match = %t, want %t", i, match, tt.match)
+		}
+	}
+
+	for _, asset := range []string{"order:ORD-2", "order:ORD-3", "shipment:SHP-1", "ORD-1"} {
+		if _, err := env.verifyPrivate(asset, orderPrivate); err == nil {
+			t.Errorf("verification of %s succeeded", asset)
+		}
+	}
+	err := env.submit(RoleAuditor, "VerifyPrivateHash", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.contract.VerifyPrivateHash(ctx, "order:ORD-1")
+		return err
+	})
+	if err == nil {
+		t.Error("verification without private details succeeded")
+	}
+}
+
+func TestVerifyPrivateHashAfterRemove(t *testing.T) {
+	env := newTestEnv(t)
+	if err := env.createOrderWithItems("ORD-1", pallet(10), pallet(2)); err != nil {
+		t.Fatal(err)
+	}
+	env.mustSubmit(RoleSeller, "RemoveLineItem", func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.RemoveLineItem(ctx, "ORD-1", 1)
+	})
+
+	// The details as read keep line 2, which must not be numbered 1 again
+	details := env.privateDetails("ORD-1")
+	if len(details.LineItems) != 1 || details.LineItems[0].LineNo != 2 {
+		t.Fatalf("unexpected line items after remove: %+v", details.LineItems)
+	}
+	detailsJSON, err := json.Marshal(details)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match, err := env.verifyPrivate("order:ORD-1", string(detailsJSON)); err != nil || !match {
+		t.Errorf("private details read after a remove do not match: %t, %v", match, err)
+	}
+
+	renumbered := strings.Replace(string(detailsJSON), `"lineNo":2`, `"lineNo":1`, 1)
+	if match, err := env.verifyPrivate("order:ORD-1", renumbered); err != nil || match {
+		t.Errorf("renumbered private details matched: %t, %v", match, err)
+	}
+}
+
+func TestUpdatePrivateOrder(t *testing.T) {
+	env := newTestEnv(t)
+	if err := env.createPrivateOrder("ORD-1", orderPrivate); err != nil {
+		t.Fatal(err)
+	}
+	hash := env.readOrder("ORD-1").PrivateHash
+
+	err := env.submit(RoleSeller, "UpdateOrder", func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.UpdateOrder(ctx, "ORD-1", "2024-03-02", "12 pallets", "CreditCard")
+	})
+	if err == nil {
+		t.Error("a private order was updated without its private details")
+	}
+	err = env.submit(RoleSeller, "AddLineItem", func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.AddLineItem(ctx, "ORD-1")
+	})
+	if err == nil {
+		t.Error("a line item was added without passing it in the transient map")
+	}
+
+	updated := strings.Replace(orderPrivate, "INV-SECRET-1", "INV-SECRET-2", 1)
+	err = env.submitPrivate(RoleSeller, "UpdateOrder", updated, func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.UpdateOrder(ctx, "ORD-1", "2024-03-02", "12 pallets", "CreditCard")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order := env.readOrder("ORD-1"); order.PrivateHash == hash || order.OrderDetail != "12 pallets" {
+		t.Errorf("unexpected updated order: %+v", order)
+	}
+	if match, err := env.verifyPrivate("order:ORD-1", updated); err != nil || !match {
+		t.Errorf("updated private details do not match: %t, %v", match, err)
+	}
+
+	// Orders recorded with a public invoice and line items can be made private
+	env.stub.PutCommittedState(orderKey(t, env, "ORD-2"), []byte(`{"docType":"order","orderNo":"ORD-2","date":"2024-03-01","invoice":"INV-OLD",`+
+		`"lineItems":[{"lineNo":1,"sku":"PAL-1","quantity":1,"unit":"pcs","unitPrice":{"amount":100,"currency":"EUR"},"taxRate":0}],`+
+		`"subtotal":{"amount":100,"currency":"EUR"},"tax":{"amount":0,"currency":"EUR"},"total":{"amount":100,"currency":"EUR"},`+
+		`"paymentMethod":"ACH","status":"Created","version":1}`))
+	err = env.submitPrivate(RoleSeller, "UpdateOrder", orderPrivate, func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.UpdateOrder(ctx, "ORD-2", "2024-03-02", "12 pallets", "CreditCard")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order := env.readOrder("ORD-2"); order.Invoice != "" || order.LineItems != nil || order.Total != nil || order.PrivateHash == "" {
+		t.Errorf("order was not made private: %+v", order)
+	}
+}
+
+func TestDeletePrivateOrder(t *testing.T) {
+	env := newTestEnv(t)
+	if err := env.createPrivateOrder("ORD-1", orderPrivate); err != nil {
+		t.Fatal(err)
+	}
+	env.mustSubmit(RoleSeller, "DeleteOrder", func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.DeleteOrder(ctx, "ORD-1")
+	})
+
+	details, err := env.stub.GetPrivateData(orderPrivateCollection, orderKey(t, env, "ORD-1"))
+	if err != nil || details != nil {
+		t.Errorf("private details outlived the order: %s, %v", details, err)
+	}
+}
+
+func TestCreatePrivateTransaction(t *testing.T) {
+	env := newTestEnv(t)
+	env.createOrder("ORD-1")
+
+	create := func(id string, private string) error {
+		return env.submitPrivate(RoleBank, "CreateTransaction", private, func(ctx contractapi.TransactionContextInterface) error {
+			return env.contract.CreateTransaction(ctx, id, "ORD-1", "ACH", "100.00", "USD", "", "deposit")
+		})
+	}
+
+	// The rules of the payment rail apply to the private account
+	if err := create("PAY-1", `{"account":"12-34","salt":"`+testSalt+`"}`); err == nil {
+		t.Error("an invalid private account was accepted")
+	}
+	private := `{"account":"1234567890","salt":"` + testSalt + `"}`
+	if err := create("PAY-1", private); err != nil {
+		t.Fatal(err)
+	}
+
+	payment := env.readPayment("PAY-1")
+	if payment.Account != "" || payment.PrivateHash == "" {
+		t.Errorf("the account leaked into the public payment: %+v", payment)
+	}
+
+	var details *PaymentPrivateDetails
+	env.mustSubmit(RoleBank, "ReadTransactionPrivateDetails", func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		details, err = env.contract.ReadTransactionPrivateDetails(ctx, "PAY-1")
+		return err
+	})
+	if details.ID != "PAY-1" || details.Account != "1234567890" {
+		t.Errorf("unexpected private details: %+v", details)
+	}
+
+	if match, err := env.verifyPrivate("payment:PAY-1", private); err != nil || !match {
+		t.Errorf("private details of the payment do not match: %t, %v", match, err)
+	}
+	if match, _ := env.verifyPrivate("payment:PAY-1", `{"account":"1234567891","salt":"`+testSalt+`"}`); match {
+		t.Error("another account matched the payment")
+	}
+
+	err := env.submitPrivate(RoleBank, "CreateTransaction", private, func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.CreateTransaction(ctx, "PAY-2", "ORD-1", "ACH", "100.00", "USD", "1234567890", "deposit")
+	})
+	if got := fieldRules(validationErrors(t, err)); got["account"] != "private" {
+		t.Errorf("rejected fields = %v, want a private error on account", got)
+	}
+	err = env.submit(RoleBank, "CreateTransaction", func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.CreateTransaction(ctx, "PAY-2", "ORD-1", "ACH", "100.00", "USD", "1234567890", "deposit")
+	})
+	if got := fieldRules(validationErrors(t, err)); got["account"] != "private" {
+		t.Errorf("rejected fields = %v, want a private error on a public account", got)
+	}
+
+	var shown TransactionData
+	if err := json.Unmarshal(env.stub.CommittedState(paymentKey(t, env, "PAY-1")), &shown); err != nil || shown.Account != "" {
+		t.Errorf("the public payment holds the account: %+v, %v", shown, err)
+	}
+}
+
+// paymentKey returns the world state key of a payment
+func paymentKey(t *testing.T, env *testEnv, id string) string {
+	t.Helper()
+	key, err := env.stub.CreateCompositeKey(paymentObjectType, []string{id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
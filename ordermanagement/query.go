@@ -0,0 +1,380 @@
+package ordermanagement
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// dateLayout is the layout of the order date
+const dateLayout = "2006-01-02"
+
+// maxSelectorDepth bounds the nesting of the selectors accepted by QueryOrders
+const maxSelectorDepth = 8
+
+// selectorOperators lists the CouchDB selector operators accepted by QueryOrders
+var selectorOperators = map[string]bool{
+	"$and": true, "$or": true, "$not": true, "$nor": true,
+	"$eq": true, "$ne": true, "$gt": true, "$gte": true, "$lt": true, "$lte": true,
+	"$exists": true, "$type": true, "$in": true, "$nin": true, "$size": true,
+	"$mod": true, "$regex": true, "$all": true, "$elemMatch": true, "$allMatch": true,
+}
+
+// Design documents and names of the indexes shipped in META-INF/statedb/couchdb/indexes
+var (
+	statusIndex        = []string{"_design/indexStatusDoc", "indexStatus"}
+	dateIndex          = []string{"_design/indexDateDoc", "indexDate"}
+	paymentMethodIndex = []string{"_design/indexPaymentMethodDoc", "indexPaymentMethod"}
+	invoiceIndex       = []string{"_design/indexInvoiceDoc", "indexInvoice"}
+)
+
+// privateInvoiceIndex is the index shipped in
+// META-INF/statedb/couchdb/collections/orderPrivateDetails/indexes
+var privateInvoiceIndex = []string{"_design/indexPrivateInvoiceDoc", "indexPrivateInvoice"}
+
+// orderQuery is a CouchDB query restricted to order documents
+type orderQuery struct {
+	Selector map[string]interface{} `json:"selector"`
+	UseIndex []string               `json:"use_index,omitempty"`
+}
+
+// newOrderQuery returns a query for the orders matching the selector. The selector is
+// nested under $and next to the document type, so it can narrow the result down but
+// never reach documents other than orders. Archived and voided orders are left out
+// unless listArchived is set
+func newOrderQuery(selector map[string]interface{}, index []string, listArchived bool) orderQuery {
+	query := orderQuery{
+		Selector: map[string]interface{}{
+			"docType": orderObjectType,
+			"$and":    []interface{}{selector},
+		},
+		UseIndex: index,
+	}
+	if !listArchived {
+		// $not rather than $nin keeps the orders written before the lifecycle, which have no status
+		query.Selector["$not"] = map[string]interface{}{"status": map[string]interface{}{"$in": archivedStatuses}}
+	}
+	return query
+}
+
+// QueryOrders returns the orders matching a CouchDB selector such as {"status":"Shipped"}.
+// Only the selector itself is accepted, not a full query, and only the usual
+// comparison, logical and array operators may be used. Like the other listings it leaves
+// out archived and voided orders, which QueryOrdersByStatus returns
+func (s *SmartContract) QueryOrders(ctx contractapi.TransactionContextInterface, selectorJSON string) ([]QueryResult, error) {
+	logger := newLogger(ctx)
+
+	// Log parameter details
+	logger.Debug("Querying orders", "selector", selectorJSON)
+
+	// Validate the arguments
+	err := validate(ctx,
+		arg("selector", selectorJSON, required),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	selector, err := parseSelector(selectorJSON)
+	if err != nil {
+		return nil, invalidArgument("invalid selector: %v", err)
+	}
+
+	return queryOrders(ctx, newOrderQuery(selector, nil, false))
+}
+
+// QueryOrdersWithPagination returns a page of at most pageSize orders matching a
+// CouchDB selector, see QueryOrders and GetOrdersWithPagination
+func (s *SmartContract) QueryOrdersWithPagination(ctx contractapi.TransactionContextInterface, selectorJSON string, pageSize int32, bookmark string) (*PaginatedQueryResult, error) {
+	logger := newLogger(ctx)
+
+	// Log parameter details
+	logger.Debug("Querying page of orders", "selector", selectorJSON, "pageSize", pageSize, "bookmark", bookmark)
+
+	// Validate the arguments
+	err := validate(ctx,
+		arg("selector", selectorJSON, required),
+		intArg("pageSize", int(pageSize), atLeast(1), atMost(maxPageSize)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	selector, err := parseSelector(selectorJSON)
+	if err != nil {
+		return nil, invalidArgument("invalid selector: %v", err)
+	}
+
+	queryJSON, err := json.Marshal(newOrderQuery(selector, nil, false))
+	if err != nil {
+		return nil, err
+	}
+
+	resultsIterator, metadata, err := ctx.GetStub().GetQueryResultWithPagination(string(queryJSON), pageSize, bookmark)
+	if err != nil {
+		return nil, internalError("failed to query orders: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	records, err := orderQueryResults(ctx, resultsIterator, []QueryResult{})
+	if err != nil {
+		return nil, err
+	}
+
+	// Log the success of the operation
+	logger.Info("Orders queried", "count", metadata.FetchedRecordsCount)
+
+	return &PaginatedQueryResult{
+		Records:             records,
+		FetchedRecordsCount: metadata.FetchedRecordsCount,
+		Bookmark:            metadata.Bookmark,
+	}, nil
+}
+
+// QueryOrdersByStatus returns the orders in the given lifecycle status, including the
+// Archived and Voided statuses
+func (s *SmartContract) QueryOrdersByStatus(ctx contractapi.TransactionContextInterface, status string) ([]QueryResult, error) {
+	logger := newLogger(ctx)
+
+	// Log parameter details
+	logger.Debug("Querying orders by status", "status", status)
+
+	// Validate the arguments
+	err := validate(ctx,
+		arg("status", status, required, oneOf(orderStatuses()...)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return queryOrders(ctx, newOrderQuery(map[string]interface{}{"status": status}, statusIndex, true))
+}
+
+// QueryOrdersByDateRange returns the orders dated between startDate and endDate
+// inclusive, both given as YYYY-MM-DD
+func (s *SmartContract) QueryOrdersByDateRange(ctx contractapi.TransactionContextInterface, startDate string, endDate string) ([]QueryResult, error) {
+	logger := newLogger(ctx)
+
+	// Log parameter details
+	logger.Debug("Querying orders by date", "startDate", startDate, "endDate", endDate)
+
+	// Validate the arguments
+	err := validate(ctx,
+		arg("startDate", startDate, required, isoDate),
+		arg("endDate", endDate, required, isoDate),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	start, err := time.Parse(dateLayout, startDate)
+	if err != nil {
+		return nil, invalidArgument("the start date %q is not of the form YYYY-MM-DD", startDate)
+	}
+	end, err := time.Parse(dateLayout, endDate)
+	if err != nil {
+		return nil, invalidArgument("the end date %q is not of the form YYYY-MM-DD", endDate)
+	}
+	if end.Before(start) {
+		return nil, invalidArgument("the end date %s is before the start date %s", endDate, startDate)
+	}
+
+	selector := map[string]interface{}{"date": map[string]interface{}{"$gte": startDate, "$lte": endDate}}
+	return queryOrders(ctx, newOrderQuery(selector, dateIndex, false))
+}
+
+// QueryOrdersByPaymentMethod returns the orders paid with the given payment method
+func (s *SmartContract) QueryOrdersByPaymentMethod(ctx contractapi.TransactionContextInterface, paymentMethod string) ([]QueryResult, error) {
+	logger := newLogger(ctx)
+
+	// Log parameter details
+	logger.Debug("Querying orders by payment method", "paymentMethod", paymentMethod)
+
+	// Validate the arguments
+	err := validate(ctx,
+		arg("paymentMethod", paymentMethod, required),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return queryOrders(ctx, newOrderQuery(map[string]interface{}{"paymentMethod": paymentMethod}, paymentMethodIndex, false))
+}
+
+// QueryOrdersByInvoice returns the orders carrying the given invoice number, whether it is
+// on the public order, as for the orders created before private details, or kept in the
+// orderPrivateDetails collection. The private invoices are only found on the peers of the
+// collection members and for their clients
+func (s *SmartContract) QueryOrdersByInvoice(ctx contractapi.TransactionContextInterface, invoice string) ([]QueryResult, error) {
+	logger := newLogger(ctx)
+
+	// Log parameter details
+	logger.Debug("Querying orders by invoice", "invoice", invoice)
+
+	// Validate the arguments
+	err := validate(ctx,
+		arg("invoice", invoice, required),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := queryOrders(ctx, newOrderQuery(map[string]interface{}{"invoice": invoice}, invoiceIndex, false))
+	if err != nil {
+		return nil, err
+	}
+
+	private, err := queryPrivateInvoice(ctx, invoice)
+	if err != nil {
+		return nil, err
+	}
+	for _, result := range private {
+		if !containsResult(results, result.Key) {
+			results = append(results, result)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Key < results[j].Key })
+
+	return results, nil
+}
+
+// queryPrivateInvoice returns the listed orders whose private details in the
+// orderPrivateDetails collection carry the given invoice number
+func queryPrivateInvoice(ctx contractapi.TransactionContextInterface, invoice string) ([]QueryResult, error) {
+	queryJSON, err := json.Marshal(orderQuery{
+		Selector: map[string]interface{}{"invoice": invoice},
+		UseIndex: privateInvoiceIndex,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetPrivateDataQueryResult(orderPrivateCollection, string(queryJSON))
+	if err != nil {
+		return nil, internalError("failed to query collection %s: %v", orderPrivateCollection, err)
+	}
+	defer resultsIterator.Close()
+
+	results := []QueryResult{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, internalError("failed to read query results: %v", err)
+		}
+
+		// The private details are kept under the key of the order in the world state
+		orderJSON, err := ctx.GetStub().GetState(queryResponse.Key)
+		if err != nil {
+			return nil, internalError("failed to read order %s from world state: %v", queryResponse.Key, err)
+		}
+		if orderJSON == nil {
+			continue
+		}
+
+		var order Order
+		err = json.Unmarshal(orderJSON, &order)
+		if err != nil {
+			return nil, internalError("failed to decode order %s: %v", queryResponse.Key, err)
+		}
+
+		orderNo, err := assetID(ctx, queryResponse.Key)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, QueryResult{Key: orderNo, Record: &order})
+	}
+
+	return listedOrders(results), nil
+}
+
+// containsResult reports whether the results hold the order with the given key
+func containsResult(results []QueryResult, key string) bool {
+	for _, result := range results {
+		if result.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// queryOrders runs a rich query and returns the matching orders
+func queryOrders(ctx contractapi.TransactionContextInterface, query orderQuery) ([]QueryResult, error) {
+	logger := newLogger(ctx)
+
+	queryJSON, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetQueryResult(string(queryJSON))
+	if err != nil {
+		return nil, internalError("failed to query orders: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	results, err := orderQueryResults(ctx, resultsIterator, []QueryResult{})
+	if err != nil {
+		return nil, err
+	}
+
+	// Log the success of the operation
+	logger.Info("Orders queried", "count", len(results))
+
+	return results, nil
+}
+
+// parseSelector decodes a selector and checks that it only uses accepted operators
+func parseSelector(selectorJSON string) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader([]byte(selectorJSON)))
+	decoder.UseNumber()
+
+	var selector map[string]interface{}
+	if err := decoder.Decode(&selector); err != nil {
+		return nil, fmt.Errorf("the selector must be a JSON object: %v", err)
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("the selector must be a single JSON object")
+	}
+	if selector == nil {
+		return nil, fmt.Errorf("the selector must be a JSON object")
+	}
+	if _, ok := selector["selector"]; ok {
+		return nil, fmt.Errorf("pass the selector itself rather than a full query")
+	}
+
+	return selector, validateSelector(selector, 1)
+}
+
+// validateSelector walks a decoded selector and rejects unknown operators and excessive nesting
+func validateSelector(value interface{}, depth int) error {
+	if depth > maxSelectorDepth {
+		return fmt.Errorf("the selector is nested deeper than %d levels", maxSelectorDepth)
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if strings.HasPrefix(key, "$") && !selectorOperators[key] {
+				return fmt.Errorf("the operator %s is not allowed", key)
+			}
+			if key == "" {
+				return fmt.Errorf("field names must not be empty")
+			}
+			if err := validateSelector(child, depth+1); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			if err := validateSelector(child, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
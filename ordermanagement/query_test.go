@@ -0,0 +1,262 @@
+package ordermanagement
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// queryEnv returns a ledger holding three orders in different statuses, dates and payment
+// methods next to a payment and a shipment. The invoice of ORD-3 is kept private
+func queryEnv(t *testing.T) *testEnv {
+	env := newTestEnv(t)
+	orders := []struct{ orderNo, date, paymentMethod string }{
+		{"ORD-1", "2024-03-01", "ACH"},
+		{"ORD-2", "2024-03-15", "CreditCard"},
+	}
+	for _, o := range orders {
+		env.mustSubmit(RoleSeller, "CreateOrder", func(ctx contractapi.TransactionContextInterface) error {
+			return env.contract.CreateOrder(ctx, o.orderNo, o.date, "pallets", o.paymentMethod)
+		})
+	}
+	err := env.submitPrivate(RoleSeller, "CreateOrder", `{"invoice":"INV-ORD-3","salt":"`+testSalt+`"}`, func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.CreateOrder(ctx, "ORD-3", "2024-04-02", "pallets", "CreditCard")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := confirm.run(env, "ORD-2"); err != nil {
+		t.Fatal(err)
+	}
+	env.createPayment("PAY-1", "ORD-1")
+	env.createShipment("SHP-1", "ORD-1")
+	return env
+}
+
+// query runs a query function as an auditor and returns the keys of the matching orders
+func (e *testEnv) query(function string, fn func(ctx contractapi.TransactionContextInterface) ([]QueryResult, error)) (string, error) {
+	var results []QueryResult
+	err := e.submit(RoleAuditor, function, func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		results, err = fn(ctx)
+		return err
+	})
+
+	var keys []string
+	for _, result := range results {
+		keys = append(keys, result.Key)
+	}
+	return strings.Join(keys, ","), err
+}
+
+func TestQueryOrders(t *testing.T) {
+	tests := []struct {
+		selector string
+		want     string
+	}{
+		{`{}`, "ORD-1,ORD-2,ORD-3"},
+		{`{"paymentMethod":"CreditCard"}`, "ORD-2,ORD-3"},
+		{`{"status":{"$in":["Confirmed","Packing"]}}`, "ORD-2"},
+		{`{"$or":[{"orderNo":"ORD-1"},{"orderNo":"ORD-3"}]}`, "ORD-1,ORD-3"},
+		{`{"transitions":{"$elemMatch":{"to":"Confirmed"}}}`, "ORD-2"},
+		{`{"docType":"payment"}`, ""},
+		{`{"$or":[{"docType":"payment"},{"docType":"shipment"}]}`, ""},
+		{`{"amount":{"$exists":true}}`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			env := queryEnv(t)
+			got, err := env.query("QueryOrders", func(ctx contractapi.TransactionContextInterface) ([]QueryResult, error) {
+				return env.contract.QueryOrders(ctx, tt.selector)
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryOrdersInvalidSelector(t *testing.T) {
+	for _, selector := range []string{
+		``,
+		`null`,
+		`[]`,
+		`"status"`,
+		`{"status":"Created"} {}`,
+		`{"selector":{"status":"Created"}}`,
+		`{"status":{"$where":"1"}}`,
+		`{"$text":"pallets"}`,
+		`{"a":{"b":{"c":{"d":{"e":{"f":{"g":{"h":1}}}}}}}}`,
+	} {
+		env := newTestEnv(t)
+		_, err := env.query("QueryOrders", func(ctx contractapi.TransactionContextInterface) ([]QueryResult, error) {
+			return env.contract.QueryOrders(ctx, selector)
+		})
+		if err == nil {
+			t.Errorf("selector %q was accepted", selector)
+		}
+	}
+}
+
+func TestQueryOrdersWithPagination(t *testing.T) {
+	env := queryEnv(t)
+
+	var keys []string
+	bookmark := ""
+	for pages := 0; pages == 0 || bookmark != ""; pages++ {
+		if pages == 3 {
+			t.Fatal("paging did not end after 3 pages")
+		}
+		var result *PaginatedQueryResult
+		env.mustSubmit(RoleAuditor, "QueryOrdersWithPagination", func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			result, err = env.contract.QueryOrdersWithPagination(ctx, `{"date":{"$gte":"2024-03-01"}}`, 2, bookmark)
+			return err
+		})
+		for _, record := range result.Records {
+			keys = append(keys, record.Key)
+		}
+		bookmark = result.Bookmark
+	}
+
+	if strings.Join(keys, ",") != "ORD-1,ORD-2,ORD-3" {
+		t.Errorf("paged through %v", keys)
+	}
+}
+
+func TestQueryOrdersByField(t *testing.T) {
+	tests := []struct {
+		name     string
+		function string
+		query    func(s *SmartContract, ctx contractapi.TransactionContextInterface) ([]QueryResult, error)
+		want     string
+		wantErr  bool
+	}{
+		{"status", "QueryOrdersByStatus", func(s *SmartContract, ctx contractapi.TransactionContextInterface) ([]QueryResult, error) {
+			return s.QueryOrdersByStatus(ctx, "Created")
+		}, "ORD-1,ORD-3", false},
+		{"unknown status", "QueryOrdersByStatus", func(s *SmartContract, ctx contractapi.TransactionContextInterface) ([]QueryResult, error) {
+			return s.QueryOrdersByStatus(ctx, "Lost")
+		}, "", true},
+		{"date range", "QueryOrdersByDateRange", func(s *SmartContract, ctx contractapi.TransactionContextInterface) ([]QueryResult, error) {
+			return s.QueryOrdersByDateRange(ctx, "2024-03-01", "2024-03-31")
+		}, "ORD-1,ORD-2", false},
+		{"single day", "QueryOrdersByDateRange", func(s *SmartContract, ctx contractapi.TransactionContextInterface) ([]QueryResult, error) {
+			return s.QueryOrdersByDateRange(ctx, "2024-04-02", "2024-04-02")
+		}, "ORD-3", false},
+		{"reversed date range", "QueryOrdersByDateRange", func(s *SmartContract, ctx contractapi.TransactionContextInterface) ([]QueryResult, error) {
+			return s.QueryOrdersByDateRange(ctx, "2024-03-31", "2024-03-01")
+		}, "", true},
+		{"malformed date", "QueryOrdersByDateRange", func(s *SmartContract, ctx contractapi.TransactionContextInterface) ([]QueryResult, error) {
+			return s.QueryOrdersByDateRange(ctx, "03/01/2024", "2024-03-31")
+		}, "", true},
+		{"payment method", "QueryOrdersByPaymentMethod", func(s *SmartContract, ctx contractapi.TransactionContextInterface) ([]QueryResult, error) {
+			return s.QueryOrdersByPaymentMethod(ctx, "ACH")
+		}, "ORD-1", false},
+		{"empty payment method", "QueryOrdersByPaymentMethod", func(s *SmartContract, ctx contractapi.TransactionContextInterface) ([]QueryResult, error) {
+			return s.QueryOrdersByPaymentMethod(ctx, "")
+		}, "", true},
+		{"invoice", "QueryOrdersByInvoice", func(s *SmartContract, ctx contractapi.TransactionContextInterface) ([]QueryResult, error) {
+			return s.QueryOrdersByInvoice(ctx, "INV-ORD-3")
+		}, "ORD-3", false},
+		{"unknown invoice", "QueryOrdersByInvoice", func(s *SmartContract, ctx contractapi.TransactionContextInterface) ([]QueryResult, error) {
+			return s.QueryOrdersByInvoice(ctx, "INV-ORD-9")
+		}, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := queryEnv(t)
+			got, err := env.query(tt.function, func(ctx contractapi.TransactionContextInterface) ([]QueryResult, error) {
+				return tt.query(env.contract, ctx)
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %t", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMigratedOrdersAreQueryable(t *testing.T) {
+	env := newTestEnv(t)
+	env.stub.PutCommittedState("ORD-1", []byte(`{"orderNo":"ORD-1","invoice":"INV-1"}`))
+	env.mustSubmit(RoleAdmin, "MigrateToCompositeKeys", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.contract.MigrateToCompositeKeys(ctx)
+		return err
+	})
+
+	got, err := env.query("QueryOrdersByInvoice", func(ctx contractapi.TransactionContextInterface) ([]QueryResult, error) {
+		return env.contract.QueryOrdersByInvoice(ctx, "INV-1")
+	})
+	if err != nil || got != "ORD-1" {
+		t.Errorf("got %q, %v, want the migrated order", got, err)
+	}
+}
+
+func TestQueryArchivedPrivateInvoice(t *testing.T) {
+	env := queryEnv(t)
+	if err := env.archiveOrder("ORD-3", "duplicate"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := env.query("QueryOrdersByInvoice", func(ctx contractapi.TransactionContextInterface) ([]QueryResult, error) {
+		return env.contract.QueryOrdersByInvoice(ctx, "INV-ORD-3")
+	})
+	if err != nil || got != "" {
+		t.Errorf("got %q, %v, want the archived order left out", got, err)
+	}
+}
+
+func TestCouchDBIndexes(t *testing.T) {
+	for _, index := range [][]string{statusIndex, dateIndex, paymentMethodIndex, invoiceIndex} {
+		name := index[1]
+		data, err := os.ReadFile(filepath.Join("..", "META-INF", "statedb", "couchdb", "indexes", name+".json"))
+		if err != nil {
+			t.Errorf("index %s is not shipped: %v", name, err)
+			continue
+		}
+
+		var definition struct {
+			Index struct {
+				Fields []string `json:"fields"`
+			} `json:"index"`
+			DDoc string `json:"ddoc"`
+			Name string `json:"name"`
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(data, &definition); err != nil {
+			t.Errorf("index %s is not valid JSON: %v", name, err)
+			continue
+		}
+		if "_design/"+definition.DDoc != index[0] || definition.Name != name || definition.Type != "json" {
+			t.Errorf("index %s does not match the query hint %v: %+v", name, index, definition)
+		}
+		if len(definition.Index.Fields) == 0 || definition.Index.Fields[0] != "docType" {
+			t.Errorf("index %s does not lead with docType: %v", name, definition.Index.Fields)
+		}
+	}
+
+	name := privateInvoiceIndex[1]
+	data, err := os.ReadFile(filepath.Join("..", "META-INF", "statedb", "couchdb", "collections", orderPrivateCollection, "indexes", name+".json"))
+	if err != nil {
+		t.Fatalf("index %s of collection %s is not shipped: %v", name, orderPrivateCollection, err)
+	}
+	var definition struct {
+		DDoc string `json:"ddoc"`
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(data, &definition); err != nil || "_design/"+definition.DDoc != privateInvoiceIndex[0] || definition.Name != name {
+		t.Errorf("index %s does not match the query hint %v: %+v, %v", name, privateInvoiceIndex, definition, err)
+	}
+}
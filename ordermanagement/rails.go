@@ -0,0 +1,361 @@
+package ordermanagement
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// paymentRailObjectType is the composite key namespace of the payment rail registry
+const paymentRailObjectType = "paymentrail"
+
+// railTypePattern matches the name of a transaction type, e.g. ACH or LetterOfCredit
+var railTypePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]{1,31}$`)
+
+// PaymentRail describes a transaction type accepted by CreateTransaction and the rules a
+// payment of that type must follow. An empty AccountPattern accepts any account, including
+// none, and an empty Currencies list accepts any currency. When RequiredDetails is set the
+// transaction details must be a JSON object holding a non-empty string for each listed field
+type PaymentRail struct {
+	Type            TransactionType `json:"type"`
+	Description     string          `json:"description"`
+	Enabled         bool            `json:"enabled"`
+	AccountPattern  string          `json:"accountPattern,omitempty" metadata:",optional"`
+	Currencies      []string        `json:"currencies,omitempty" metadata:",optional"`
+	RequiredDetails []string        `json:"requiredDetails,omitempty" metadata:",optional"`
+	UpdatedAt       string          `json:"updatedAt,omitempty" metadata:",optional"`
+}
+
+// defaultPaymentRails are the rails accepted until an admin registers a rail of the same
+// type, which then replaces the default
+var defaultPaymentRails = map[TransactionType]PaymentRail{
+	ACHTransaction: {
+		Type:           ACHTransaction,
+		Description:    "Automated Clearing House transfer, account is the US bank account number",
+		Enabled:        true,
+		AccountPattern: `^[0-9]{4,17}$`,
+		Currencies:     []string{"USD"},
+	},
+	CreditCardTransaction: {
+		Type:           CreditCardTransaction,
+		Description:    "Card payment, account holds the last four digits of the card only",
+		Enabled:        true,
+		AccountPattern: `^[0-9]{4}$`,
+	},
+	WireTransaction: {
+		Type:            WireTransaction,
+		Description:     "International wire transfer over SWIFT, account is the IBAN or account number of the beneficiary",
+		Enabled:         true,
+		AccountPattern:  `^[A-Z0-9]{5,34}$`,
+		RequiredDetails: []string{"bic"},
+	},
+	SEPATransaction: {
+		Type:           SEPATransaction,
+		Description:    "SEPA credit transfer, account is the IBAN of the beneficiary",
+		Enabled:        true,
+		AccountPattern: `^[A-Z]{2}[0-9]{2}[A-Z0-9]{11,30}$`,
+		Currencies:     []string{"EUR"},
+	},
+	LetterOfCreditTransaction: {
+		Type:            LetterOfCreditTransaction,
+		Description:     "Documentary letter of credit, account is the account of the beneficiary at the advising bank",
+		Enabled:         true,
+		RequiredDetails: []string{"lcNumber", "issuingBank", "expiryDate"},
+	},
+	CashTransaction: {
+		Type:            CashTransaction,
+		Description:     "Cash payment, evidenced by a receipt",
+		Enabled:         true,
+		RequiredDetails: []string{"receiptNo"},
+	},
+	CryptoTransaction: {
+		Type:            CryptoTransaction,
+		Description:     "Crypto currency transfer, account is the wallet address of the beneficiary",
+		Enabled:         true,
+		AccountPattern:  `^[A-Za-z0-9]{20,100}$`,
+		RequiredDetails: []string{"network", "txHash"},
+	},
+}
+
+// validate checks that the rail itself is well formed
+func (r *PaymentRail) validate() error {
+	if !railTypePattern.MatchString(string(r.Type)) {
+		return fmt.Errorf("the transaction type must be 2 to 32 letters and digits starting with a letter, got %q", r.Type)
+	}
+	if r.AccountPattern != "" {
+		if _, err := regexp.Compile(r.AccountPattern); err != nil {
+			return fmt.Errorf("invalid account pattern of %s: %v", r.Type, err)
+		}
+	}
+	for _, currency := range r.Currencies {
+		if !currencyPattern.MatchString(currency) {
+			return fmt.Errorf("the currencies of %s must be ISO 4217 codes, got %q", r.Type, currency)
+		}
+	}
+	seen := map[string]bool{}
+	for _, field := range r.RequiredDetails {
+		if field == "" {
+			return fmt.Errorf("the required details of %s must not contain an empty field name", r.Type)
+		}
+		if seen[field] {
+			return fmt.Errorf("the required detail %s of %s is listed twice", field, r.Type)
+		}
+		seen[field] = true
+	}
+	return nil
+}
+
+// check validates a payment against the rules of the rail
+func (r *PaymentRail) check(account string, amount Money, transactionDetails string) error {
+	if !r.Enabled {
+		return fmt.Errorf("the transaction type %s is disabled", r.Type)
+	}
+	if r.AccountPattern != "" {
+		pattern, err := regexp.Compile(r.AccountPattern)
+		if err != nil {
+			return fmt.Errorf("invalid account pattern of %s: %v", r.Type, err)
+		}
+		if !pattern.MatchString(account) {
+			return fmt.Errorf("the account is not valid for %s", r.Type)
+		}
+	}
+	if len(r.Currencies) > 0 && !contains(r.Currencies, amount.Currency) {
+		return fmt.Errorf("%s payments must be in one of %v, got %s", r.Type, r.Currencies, amount.Currency)
+	}
+	if len(r.RequiredDetails) == 0 {
+		return nil
+	}
+
+	var details map[string]interface{}
+	if err := json.Unmarshal([]byte(transactionDetails), &details); err != nil || details == nil {
+		return fmt.Errorf("the details of %s payments must be a JSON object with the fields %v", r.Type, r.RequiredDetails)
+	}
+	for _, field := range r.RequiredDetails {
+		if value, ok := details[field].(string); !ok || value == "" {
+			return fmt.Errorf("the details of %s payments must contain a non-empty %s", r.Type, field)
+		}
+	}
+	return nil
+}
+
+// paymentRail returns the rail registered for a transaction type, falling back to the
+// default rails, and fails for unknown types
+func paymentRail(ctx contractapi.TransactionContextInterface, transactionType string) (*PaymentRail, error) {
+	key, err := assetKey(ctx, paymentRailObjectType, transactionType)
+	if err != nil {
+		return nil, err
+	}
+	railJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, internalError("failed to read payment rail %s from world state: %v", transactionType, err)
+	}
+
+	if railJSON != nil {
+		var rail PaymentRail
+		if err := json.Unmarshal(railJSON, &rail); err != nil {
+			return nil, internalError("failed to decode payment rail %s: %v", transactionType, err)
+		}
+		return &rail, nil
+	}
+
+	rail, ok := defaultPaymentRails[TransactionType(transactionType)]
+	if !ok {
+		return nil, newError(CodeNotFound, map[string]string{"type": paymentRailObjectType, "id": transactionType}, "unknown transaction type %q", transactionType)
+	}
+	return &rail, nil
+}
+
+// RegisterPaymentRail adds a transaction type to the registry or replaces the rules of an
+// existing one, including the default rails
+func (s *SmartContract) RegisterPaymentRail(ctx contractapi.TransactionContextInterface, rail PaymentRail) error {
+	// Record the transaction timestamp
+	timestamp, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	logger := newLogger(ctx)
+
+	// Log the start of the function
+	logger.Debug("Registering payment rail", "transactionType", rail.Type, "enabled", rail.Enabled)
+
+	// Validate the arguments
+	err = validate(ctx,
+		arg("type", string(rail.Type), required),
+		textArg("description", rail.Description),
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := rail.validate(); err != nil {
+		return wrapError(err, CodeInvalidArgument, "invalid payment rail %s", rail.Type)
+	}
+	rail.UpdatedAt = timestamp
+
+	return putPaymentRail(ctx, rail)
+}
+
+// SetPaymentRailEnabled enables or disables a transaction type. Payments of a disabled
+// type are rejected by CreateTransaction, existing payments are left untouched
+func (s *SmartContract) SetPaymentRailEnabled(ctx contractapi.TransactionContextInterface, transactionType string, enabled bool) error {
+	// Record the transaction timestamp
+	timestamp, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	logger := newLogger(ctx)
+
+	// Log parameter details
+	logger.Debug("Enabling payment rail", "transactionType", transactionType, "enabled", enabled)
+
+	// Validate the arguments
+	err = validate(ctx,
+		arg("transactionType", transactionType, required),
+	)
+	if err != nil {
+		return err
+	}
+
+	rail, err := paymentRail(ctx, transactionType)
+	if err != nil {
+		return err
+	}
+	rail.Enabled = enabled
+	rail.UpdatedAt = timestamp
+
+	return putPaymentRail(ctx, *rail)
+}
+
+// putPaymentRail writes a rail to the registry and records the mutation
+func putPaymentRail(ctx contractapi.TransactionContextInterface, rail PaymentRail) error {
+	logger := newLogger(ctx)
+
+	railJSON, err := json.Marshal(rail)
+	if err != nil {
+		return err
+	}
+
+	key, err := assetKey(ctx, paymentRailObjectType, string(rail.Type))
+	if err != nil {
+		return err
+	}
+
+	previousJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return internalError("failed to read payment rail %s from world state: %v", rail.Type, err)
+	}
+
+	err = ctx.GetStub().PutState(key, railJSON)
+	if err != nil {
+		return internalError("failed to put payment rail %s to world state: %v", rail.Type, err)
+	}
+
+	// Publish payment rail event
+	err = recordMutation(ctx, EventPaymentRailChanged, paymentRailObjectType, string(rail.Type), previousJSON, railJSON)
+	if err != nil {
+		return err
+	}
+
+	// Log the success of the operation
+	logger.Info("Payment rail saved", "transactionType", rail.Type, "enabled", rail.Enabled)
+
+	return nil
+}
+
+// GetPaymentRail returns the rules of a transaction type
+func (s *SmartContract) GetPaymentRail(ctx contractapi.TransactionContextInterface, transactionType string) (*PaymentRail, error) {
+	logger := newLogger(ctx)
+
+	// Log parameter details
+	logger.Debug("Reading payment rail", "transactionType", transactionType)
+
+	// Validate the arguments
+	err := validate(ctx,
+		arg("transactionType", transactionType, required),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rail, err := paymentRail(ctx, transactionType)
+	if err != nil {
+		return nil, err
+	}
+	return rail, nil
+}
+
+// GetPaymentRails returns every transaction type of the registry, the default rails
+// included, ordered by type
+func (s *SmartContract) GetPaymentRails(ctx contractapi.TransactionContextInterface) ([]PaymentRail, error) {
+	logger := newLogger(ctx)
+
+	results, err := paymentRails(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Log the success of the operation
+	logger.Info("Payment rails read", "count", len(results))
+
+	return results, nil
+}
+
+// paymentRails returns the rails of the registry merged over the default rails, ordered
+// by type
+func paymentRails(ctx contractapi.TransactionContextInterface) ([]PaymentRail, error) {
+	rails := map[TransactionType]PaymentRail{}
+	for transactionType, rail := range defaultPaymentRails {
+		rails[transactionType] = rail
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(paymentRailObjectType, []string{})
+	if err != nil {
+		return nil, internalError("failed to read payment rails: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, internalError("failed to read query results: %v", err)
+		}
+
+		transactionType, err := assetID(ctx, queryResponse.Key)
+		if err != nil {
+			return nil, err
+		}
+
+		var rail PaymentRail
+		err = json.Unmarshal(queryResponse.Value, &rail)
+		if err != nil {
+			return nil, internalError("failed to decode payment rail %s: %v", transactionType, err)
+		}
+		rails[rail.Type] = rail
+	}
+
+	results := make([]PaymentRail, 0, len(rails))
+	for _, rail := range rails {
+		results = append(results, rail)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Type < results[j].Type })
+	return results, nil
+}
+
+// paymentMethods returns the payment methods an order may name, which are the enabled
+// transaction types of the payment rail registry
+func paymentMethods(ctx contractapi.TransactionContextInterface) ([]string, error) {
+	rails, err := paymentRails(ctx)
+	if err != nil {
+		return nil, err
+	}
+	methods := []string{}
+	for _, rail := range rails {
+		if rail.Enabled {
+			methods = append(methods, string(rail.Type))
+		}
+	}
+	return methods, nil
+}
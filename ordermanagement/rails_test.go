@@ -0,0 +1,237 @@
+package ordermanagement
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// createTransaction creates a payment of the given rail for ORD-1 as a bank, passing the
+// account in the transient map
+func (e *testEnv) createTransaction(id string, transactionType string, amount string, currency string, account string, details string) error {
+	private := `{"account":"` + account + `","salt":"` + testSalt + `"}`
+	return e.submitPrivate(RoleBank, "CreateTransaction", private, func(ctx contractapi.TransactionContextInterface) error {
+		return e.contract.CreateTransaction(ctx, id, "ORD-1", transactionType, amount, currency, "", details)
+	})
+}
+
+func TestCreateTransactionRails(t *testing.T) {
+	env := newTestEnv(t)
+	env.createOrder("ORD-1")
+
+	valid := []struct{ transactionType, currency, account, details string }{
+		{"ACH", "USD", "1234567890", "deposit"},
+		{"CreditCard", "EUR", "4242", "order payment"},
+		{"Wire", "GBP", "GB29NWBK60161331926819", `{"bic":"NWBKGB2L"}`},
+		{"SEPA", "EUR", "DE89370400440532013000", ""},
+		{"LetterOfCredit", "USD", "", `{"lcNumber":"LC-1","issuingBank":"Bank of Trade","expiryDate":"2024-12-31"}`},
+		{"Cash", "INR", "", `{"receiptNo":"R-17"}`},
+		{"Crypto", "USD", "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", `{"network":"bitcoin","txHash":"4a5e1e"}`},
+	}
+	for i, tt := range valid {
+		id := "PAY-" + tt.transactionType
+		if err := env.createTransaction(id, tt.transactionType, "100.00", tt.currency, tt.account, tt.details); err != nil {
+			t.Errorf("case %d: %s payment rejected: %v", i, tt.transactionType, err)
+		}
+	}
+
+	invalid := []struct{ transactionType, currency, account, details, want string }{
+		{"Paypal", "USD", "1234567890", "", "unknown transaction type"},
+		{"ach", "USD", "1234567890", "", "unknown transaction type"},
+		{"", "USD", "1234567890", "", "must not be empty"},
+		{"ACH", "EUR", "1234567890", "", "must be in one of"},
+		{"ACH", "USD", "12-34", "", "is not valid for ACH"},
+		{"CreditCard", "USD", "4242424242424242", "", "is not valid for CreditCard"},
+		{"Wire", "USD", "GB29NWBK60161331926819", "wire", "must be a JSON object"},
+		{"Wire", "USD", "GB29NWBK60161331926819", `{"bic":""}`, "non-empty bic"},
+		{"SEPA", "USD", "DE89370400440532013000", "", "must be in one of"},
+		{"LetterOfCredit", "USD", "", `{"lcNumber":"LC-1"}`, "non-empty issuingBank"},
+		{"Cash", "USD", "", `{"receiptNo":17}`, "non-empty receiptNo"},
+	}
+	for i, tt := range invalid {
+		err := env.createTransaction("PAY-BAD", tt.transactionType, "100.00", tt.currency, tt.account, tt.details)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("case %d: got error %v, want %q", i, err, tt.want)
+		}
+	}
+}
+
+func TestRegisterPaymentRail(t *testing.T) {
+	env := newTestEnv(t)
+	env.createOrder("ORD-1")
+
+	escrow := PaymentRail{
+		Type:            "Escrow",
+		Description:     "Payment held by an escrow agent",
+		Enabled:         true,
+		Currencies:      []string{"USD", "EUR"},
+		RequiredDetails: []string{"agent"},
+	}
+	if err := env.createTransaction("PAY-1", "Escrow", "100.00", "USD", "", `{"agent":"Acme Escrow"}`); err == nil {
+		t.Error("a payment of an unregistered type was accepted")
+	}
+
+	for _, role := range []string{RoleBank, RoleSeller, RoleAuditor} {
+		err := env.submit(role, "RegisterPaymentRail", func(ctx contractapi.TransactionContextInterface) error {
+			return env.contract.RegisterPaymentRail(ctx, escrow)
+		})
+		if err == nil {
+			t.Errorf("%s registered a payment rail", role)
+		}
+	}
+	env.mustSubmit(RoleAdmin, "RegisterPaymentRail", func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.RegisterPaymentRail(ctx, escrow)
+	})
+	if event := env.lastEvent(); event.Name != EventPaymentRailChanged {
+		t.Errorf("event = %s, want %s", event.Name, EventPaymentRailChanged)
+	}
+
+	if err := env.createTransaction("PAY-1", "Escrow", "100.00", "USD", "", `{"agent":"Acme Escrow"}`); err != nil {
+		t.Errorf("payment of the registered type rejected: %v", err)
+	}
+	if err := env.createTransaction("PAY-2", "Escrow", "100.00", "GBP", "", `{"agent":"Acme Escrow"}`); err == nil {
+		t.Error("the currencies of the registered rail were not enforced")
+	}
+
+	// A registered rail replaces the default rail of the same type
+	env.mustSubmit(RoleAdmin, "RegisterPaymentRail", func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.RegisterPaymentRail(ctx, PaymentRail{Type: ACHTransaction, Description: "ACH in USD and CAD", Enabled: true, Currencies: []string{"USD", "CAD"}})
+	})
+	if err := env.createTransaction("PAY-3", "ACH", "100.00", "CAD", "any account", ""); err != nil {
+		t.Errorf("payment following the replaced ACH rules rejected: %v", err)
+	}
+
+	var rails []PaymentRail
+	env.mustSubmit(RoleAuditor, "GetPaymentRails", func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		rails, err = env.contract.GetPaymentRails(ctx)
+		return err
+	})
+	if len(rails) != len(defaultPaymentRails)+1 {
+		t.Fatalf("got %d rails, want %d", len(rails), len(defaultPaymentRails)+1)
+	}
+	for i := 1; i < len(rails); i++ {
+		if rails[i-1].Type >= rails[i].Type {
+			t.Errorf("rails are not ordered by type: %s before %s", rails[i-1].Type, rails[i].Type)
+		}
+	}
+	if rails[0].Type != ACHTransaction || rails[0].Description != "ACH in USD and CAD" || rails[0].UpdatedAt == "" {
+		t.Errorf("unexpected ACH rail: %+v", rails[0])
+	}
+
+	if entries := env.auditTrail("paymentrail:Escrow"); len(entries) != 1 || entries[0].Action != EventPaymentRailChanged {
+		t.Errorf("unexpected audit trail of the escrow rail: %+v", entries)
+	}
+}
+
+func TestRegisterInvalidPaymentRail(t *testing.T) {
+	env := newTestEnv(t)
+
+	invalid := []PaymentRail{
+		{Type: "", Enabled: true},
+		{Type: "Bank Transfer", Enabled: true},
+		{Type: "X", Enabled: true},
+		{Type: "Escrow", AccountPattern: "[0-9", Enabled: true},
+		{Type: "Escrow", Currencies: []string{"usd"}, Enabled: true},
+		{Type: "Escrow", RequiredDetails: []string{"agent", "agent"}, Enabled: true},
+		{Type: "Escrow", RequiredDetails: []string{""}, Enabled: true},
+	}
+	for i, rail := range invalid {
+		err := env.submit(RoleAdmin, "RegisterPaymentRail", func(ctx contractapi.TransactionContextInterface) error {
+			return env.contract.RegisterPaymentRail(ctx, rail)
+		})
+		if err == nil {
+			t.Errorf("case %d: invalid rail %+v was registered", i, rail)
+		}
+	}
+}
+
+func TestGetPaymentRailsUndecodable(t *testing.T) {
+	env := newTestEnv(t)
+	key, _ := env.stub.CreateCompositeKey(paymentRailObjectType, []string{"ACH"})
+	env.stub.PutCommittedState(key, []byte(`{"type":`))
+
+	err := env.submit(RoleAuditor, "GetPaymentRails", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.contract.GetPaymentRails(ctx)
+		return err
+	})
+	if decoded := decodeError(t, err); decoded.Code != CodeInternal || !strings.Contains(decoded.Message, "payment rail ACH") {
+		t.Errorf("got %+v, want an INTERNAL error naming the rail", decoded)
+	}
+}
+
+func TestSetPaymentRailEnabled(t *testing.T) {
+	env := newTestEnv(t)
+	env.createOrder("ORD-1")
+
+	setEnabled := func(transactionType string, enabled bool) error {
+		return env.submit(RoleAdmin, "SetPaymentRailEnabled", func(ctx contractapi.TransactionContextInterface) error {
+			return env.contract.SetPaymentRailEnabled(ctx, transactionType, enabled)
+		})
+	}
+
+	if err := setEnabled("Crypto", false); err != nil {
+		t.Fatal(err)
+	}
+	err := env.createTransaction("PAY-1", "Crypto", "100.00", "USD", "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", `{"network":"bitcoin","txHash":"4a5e1e"}`)
+	if err == nil || !strings.Contains(err.Error(), "disabled") {
+		t.Errorf("got error %v, want a disabled rail error", err)
+	}
+
+	var rail *PaymentRail
+	env.mustSubmit(RoleBank, "GetPaymentRail", func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		rail, err = env.contract.GetPaymentRail(ctx, "Crypto")
+		return err
+	})
+	if rail.Enabled || len(rail.RequiredDetails) != 2 {
+		t.Errorf("disabling must keep the default rules of the rail: %+v", rail)
+	}
+
+	if err := setEnabled("Crypto", true); err != nil {
+		t.Fatal(err)
+	}
+	err = env.createTransaction("PAY-1", "Crypto", "100.00", "USD", "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", `{"network":"bitcoin","txHash":"4a5e1e"}`)
+	if err != nil {
+		t.Errorf("payment of the re-enabled rail rejected: %v", err)
+	}
+
+	if err := setEnabled("Barter", true); err == nil {
+		t.Error("an unknown transaction type was enabled")
+	}
+}
+
+func TestOrderPaymentMethodRails(t *testing.T) {
+	env := newTestEnv(t)
+
+	createOrder := func(orderNo string, paymentMethod string) error {
+		return env.submit(RoleSeller, "CreateOrder", func(ctx contractapi.TransactionContextInterface) error {
+			return env.contract.CreateOrder(ctx, orderNo, "2024-03-01", "10 pallets", paymentMethod)
+		})
+	}
+
+	// Payment methods are the transaction types of the registry, spelt the same way
+	for _, paymentMethod := range []string{"Credit Card", "Letter of Credit", "Escrow"} {
+		if got := fieldRules(validationErrors(t, createOrder("ORD-1", paymentMethod))); got["paymentMethod"] != "enum" {
+			t.Errorf("payment method %q: rejected fields = %v, want an enum error", paymentMethod, got)
+		}
+	}
+	if err := createOrder("ORD-1", "LetterOfCredit"); err != nil {
+		t.Errorf("payment method of a default rail rejected: %v", err)
+	}
+
+	env.mustSubmit(RoleAdmin, "RegisterPaymentRail", func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.RegisterPaymentRail(ctx, PaymentRail{Type: "Escrow", Description: "Payment held by an escrow agent", Enabled: true})
+	})
+	if err := createOrder("ORD-2", "Escrow"); err != nil {
+		t.Errorf("payment method of a registered rail rejected: %v", err)
+	}
+
+	env.mustSubmit(RoleAdmin, "SetPaymentRailEnabled", func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.SetPaymentRailEnabled(ctx, "Escrow", false)
+	})
+	if got := fieldRules(validationErrors(t, createOrder("ORD-3", "Escrow"))); got["paymentMethod"] != "enum" {
+		t.Errorf("payment method of a disabled rail: rejected fields = %v, want an enum error", got)
+	}
+}
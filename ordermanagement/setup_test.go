@@ -0,0 +1,141 @@
+package ordermanagement
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/ravinayag/Chaincode-supplychain-Audit-trail/internal/fabrictest"
+)
+
+// testStart is the proposal time of the first transaction of every test, each
+// following transaction is one minute later
+var testStart = time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+// testEnv runs contract functions as transactions against an in-memory ledger
+type testEnv struct {
+	t        *testing.T
+	stub     *fabrictest.Stub
+	contract *SmartContract
+	txCount  int
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	return &testEnv{
+		t:        t,
+		stub:     fabrictest.NewStub("mychannel"),
+		contract: NewSmartContract(),
+	}
+}
+
+// testIdentity returns an identity holding the given role, of Org1MSP unless the role
+// is bound to other organizations
+func testIdentity(role string) *fabrictest.ClientIdentity {
+	mspID := testMSPID(role)
+	org := strings.ToLower(strings.TrimSuffix(mspID, "MSP"))
+	return fabrictest.NewClientIdentity(fmt.Sprintf("x509::CN=%s::CN=ca.%s.example.com", role, org), mspID, role)
+}
+
+// testMSPID returns the MSP ID of the test identity holding the given role
+func testMSPID(role string) string {
+	if mspIDs, ok := roleMSPIDs[role]; ok {
+		return mspIDs[0]
+	}
+	return "Org1MSP"
+}
+
+// begin starts a transaction invoking the given function as a caller holding the given role
+func (e *testEnv) begin(role string, function string, args ...string) *contractapi.TransactionContext {
+	e.txCount++
+	e.stub.StartTransaction(fmt.Sprintf("tx%d", e.txCount), e.now(), function, args...)
+
+	ctx := &contractapi.TransactionContext{}
+	ctx.SetStub(e.stub)
+	ctx.SetClientIdentity(testIdentity(role))
+	return ctx
+}
+
+// now returns the proposal time of the current transaction
+func (e *testEnv) now() time.Time {
+	return testStart.Add(time.Duration(e.txCount-1) * time.Minute)
+}
+
+// submit runs fn as a transaction of the given function after the access check the
+// chaincode runs before every function. The writes are committed when no error is
+// returned and discarded otherwise
+func (e *testEnv) submit(role string, function string, fn func(ctx contractapi.TransactionContextInterface) error) error {
+	ctx := e.begin(role, function)
+
+	err := checkAccess(ctx)
+	if err == nil {
+		err = fn(ctx)
+	}
+
+	if err != nil {
+		e.stub.Rollback()
+		return err
+	}
+	e.stub.Commit()
+	return nil
+}
+
+// mustSubmit is like submit but fails the test on error
+func (e *testEnv) mustSubmit(role string, function string, fn func(ctx contractapi.TransactionContextInterface) error) {
+	e.t.Helper()
+	if err := e.submit(role, function, fn); err != nil {
+		e.t.Fatalf("%s failed: %v", function, err)
+	}
+}
+
+// createOrder creates an order with sample details as a seller
+func (e *testEnv) createOrder(orderNo string) {
+	e.t.Helper()
+	e.mustSubmit(RoleSeller, "CreateOrder", func(ctx contractapi.TransactionContextInterface) error {
+		return e.contract.CreateOrder(ctx, orderNo, "2024-03-01", "10 pallets", "CreditCard")
+	})
+}
+
+// createPayment creates a payment of 100.00 USD for an order as a bank, with the
+// private account of paymentPrivate
+func (e *testEnv) createPayment(id string, orderNo string) {
+	e.t.Helper()
+	err := e.submitPrivate(RoleBank, "CreateTransaction", paymentPrivate, func(ctx contractapi.TransactionContextInterface) error {
+		return e.contract.CreateTransaction(ctx, id, orderNo, "ACH", "100.00", "USD", "", "deposit")
+	})
+	if err != nil {
+		e.t.Fatalf("CreateTransaction failed: %v", err)
+	}
+}
+
+// createShipment creates a shipment for an order as a carrier
+func (e *testEnv) createShipment(id string, orderNo string) {
+	e.t.Helper()
+	e.mustSubmit(RoleCarrier, "CreateShipEngineData", func(ctx contractapi.TransactionContextInterface) error {
+		return e.contract.CreateShipEngineData(ctx, id, orderNo, "se-"+id, "https://track.example.com/"+id)
+	})
+}
+
+// readOrder reads an order as an auditor and fails the test if it does not exist
+func (e *testEnv) readOrder(orderNo string) *Order {
+	e.t.Helper()
+	var order *Order
+	e.mustSubmit(RoleAuditor, "ReadOrder", func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		order, err = e.contract.ReadOrder(ctx, orderNo)
+		return err
+	})
+	return order
+}
+
+// lastEvent returns the event of the last committed transaction and fails the test if there is none
+func (e *testEnv) lastEvent() fabrictest.Event {
+	e.t.Helper()
+	event, ok := e.stub.LastEvent()
+	if !ok {
+		e.t.Fatal("no event was emitted")
+	}
+	return event
+}
@@ -0,0 +1,29 @@
+package ordermanagement
+
+import (
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// txTime returns the timestamp set by the client in the transaction proposal. Unlike
+// the local clock it is the same on every endorsing peer
+func txTime(ctx contractapi.TransactionContextInterface) (time.Time, error) {
+	ts, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, internalError("failed to get transaction timestamp: %v", err)
+	}
+	if ts == nil {
+		return time.Time{}, internalError("the transaction has no timestamp")
+	}
+	return ts.AsTime().UTC(), nil
+}
+
+// txTimestamp returns the transaction timestamp formatted as RFC3339
+func txTimestamp(ctx contractapi.TransactionContextInterface) (string, error) {
+	t, err := txTime(ctx)
+	if err != nil {
+		return "", err
+	}
+	return t.Format(time.RFC3339), nil
+}
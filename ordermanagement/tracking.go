@@ -0,0 +1,205 @@
+package ordermanagement
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// trackingEventObjectType is the composite key namespace of the tracking events of the shipments
+const trackingEventObjectType = "shipment~tracking"
+
+// trackingStatusCodes are the ShipEngine tracking status codes accepted for a checkpoint scan
+var trackingStatusCodes = map[string]string{
+	"UN": "Unknown",
+	"AC": "Accepted",
+	"IT": "In Transit",
+	"AT": "Delivery Attempt",
+	"EX": "Exception",
+	"SP": "Delivered To Service Point",
+	"DE": "Delivered",
+}
+
+// TrackingEvent is a checkpoint scan of a shipment posted by a carrier. CarrierTimestamp
+// is the time of the scan reported by the carrier, RecordedAt the time it reached the ledger
+type TrackingEvent struct {
+	ShipmentID       string `json:"shipmentId"`
+	Sequence         int    `json:"sequence"`
+	StatusCode       string `json:"statusCode"`
+	Location         string `json:"location"`
+	CarrierTimestamp string `json:"carrierTimestamp"`
+	Description      string `json:"description"`
+	Actor            string `json:"actor"`
+	MSPID            string `json:"mspId"`
+	TxID             string `json:"txId"`
+	RecordedAt       string `json:"recordedAt"`
+}
+
+// trackingEventKey returns the key of a tracking event. The sequence number is zero
+// padded so that range queries return the events in the order they were recorded
+func trackingEventKey(ctx contractapi.TransactionContextInterface, id string, sequence int) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(trackingEventObjectType, []string{id, fmt.Sprintf("%010d", sequence)})
+}
+
+// AddTrackingEvent appends a checkpoint scan to the tracking events of a shipment. The
+// status code is a ShipEngine tracking status code such as IT or DE and the carrier
+// timestamp is given as RFC3339
+func (s *SmartContract) AddTrackingEvent(ctx contractapi.TransactionContextInterface, id string, statusCode string, location string, carrierTimestamp string, description string) error {
+	// Record the transaction timestamp
+	timestamp, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	logger := newLogger(ctx)
+
+	// Log the start of the function
+	logger.Debug("Adding tracking event", "id", id, "statusCode", statusCode, "location", location, "carrierTimestamp", carrierTimestamp, "description", description)
+
+	// Retrieve transaction ID and caller ID
+	txID := ctx.GetStub().GetTxID()
+	callerID, _ := ctx.GetClientIdentity().GetID()
+	mspID, _ := ctx.GetClientIdentity().GetMSPID()
+
+	// Validate the arguments
+	err = validate(ctx,
+		idArg("id", id),
+		arg("statusCode", statusCode, required, oneOf(sortedKeys(trackingStatusCodes)...)),
+		textArg("location", location),
+		arg("carrierTimestamp", carrierTimestamp, required, rfc3339),
+		textArg("description", description),
+	)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := trackingStatusCodes[statusCode]; !ok {
+		return invalidArgument("unknown tracking status code %q", statusCode)
+	}
+	scannedAt, err := time.Parse(time.RFC3339, carrierTimestamp)
+	if err != nil {
+		return invalidArgument("the carrier timestamp %q is not RFC3339", carrierTimestamp)
+	}
+
+	var shipment ShipEngineData
+	err = readLinked(ctx, shipmentObjectType, id, &shipment)
+	if err != nil {
+		return err
+	}
+
+	key, err := assetKey(ctx, shipmentObjectType, id)
+	if err != nil {
+		return err
+	}
+	previousBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return internalError("failed to read shipment %s from world state: %v", id, err)
+	}
+
+	event := TrackingEvent{
+		ShipmentID:       id,
+		Sequence:         shipment.TrackingEvents + 1,
+		StatusCode:       statusCode,
+		Location:         location,
+		CarrierTimestamp: scannedAt.UTC().Format(time.RFC3339),
+		Description:      description,
+		Actor:            callerID,
+		MSPID:            mspID,
+		TxID:             txID,
+		RecordedAt:       timestamp,
+	}
+
+	eventJSON, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	eventKey, err := trackingEventKey(ctx, id, event.Sequence)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().PutState(eventKey, eventJSON)
+	if err != nil {
+		return internalError("failed to put tracking event %d of shipment %s to world state: %v", event.Sequence, id, err)
+	}
+
+	// The shipment keeps the count and the latest status so that its audit trail covers every scan
+	shipment.TrackingEvents = event.Sequence
+	shipment.Status = statusCode
+	shipment.UpdatedAt = timestamp
+
+	dataBytes, err := json.Marshal(shipment)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().PutState(key, dataBytes)
+	if err != nil {
+		return internalError("failed to update shipment %s in world state: %v", id, err)
+	}
+
+	// Publish shipment event
+	err = recordMutation(ctx, EventTrackingEventAdded, shipmentObjectType, id, previousBytes, dataBytes)
+	if err != nil {
+		return err
+	}
+
+	// Log the success of the operation
+	logger.Info("Tracking event added", "id", id, "sequence", event.Sequence, "statusCode", statusCode)
+
+	return nil
+}
+
+// GetShipmentTimeline returns the tracking events of a shipment ordered by carrier
+// timestamp, events scanned at the same time keep the order they were recorded in
+func (s *SmartContract) GetShipmentTimeline(ctx contractapi.TransactionContextInterface, id string) ([]TrackingEvent, error) {
+	logger := newLogger(ctx)
+
+	// Log parameter details
+	logger.Debug("Reading shipment timeline", "id", id)
+
+	// Validate the arguments
+	err := validate(ctx,
+		idArg("id", id),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := s.ShipEngineDataExists(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, notFound(shipmentObjectType, id)
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(trackingEventObjectType, []string{id})
+	if err != nil {
+		return nil, internalError("failed to read tracking events of shipment %s: %v", id, err)
+	}
+	defer resultsIterator.Close()
+
+	events := []TrackingEvent{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, internalError("failed to read query results: %v", err)
+		}
+
+		var event TrackingEvent
+		err = json.Unmarshal(queryResponse.Value, &event)
+		if err != nil {
+			return nil, internalError("failed to decode tracking event %s: %v", queryResponse.Key, err)
+		}
+		events = append(events, event)
+	}
+
+	// Carrier timestamps are normalized to UTC, so they sort as strings
+	sort.SliceStable(events, func(i, j int) bool { return events[i].CarrierTimestamp < events[j].CarrierTimestamp })
+
+	// Log the success of the operation
+	logger.Info("Shipment timeline read", "id", id, "events", len(events))
+
+	return events, nil
+}
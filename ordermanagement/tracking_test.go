@@ -0,0 +1,150 @@
+package ordermanagement
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// addTrackingEvent posts a checkpoint scan of a shipment as a carrier
+func (e *testEnv) addTrackingEvent(id string, statusCode string, location string, carrierTimestamp string) error {
+	return e.submit(RoleCarrier, "AddTrackingEvent", func(ctx contractapi.TransactionContextInterface) error {
+		return e.contract.AddTrackingEvent(ctx, id, statusCode, location, carrierTimestamp, statusCode+" at "+location)
+	})
+}
+
+// timeline returns the tracking events of a shipment as an auditor
+func (e *testEnv) timeline(id string) []TrackingEvent {
+	e.t.Helper()
+	var events []TrackingEvent
+	e.mustSubmit(RoleAuditor, "GetShipmentTimeline", func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		events, err = e.contract.GetShipmentTimeline(ctx, id)
+		return err
+	})
+	return events
+}
+
+func TestShipmentTimeline(t *testing.T) {
+	env := newTestEnv(t)
+	env.createOrder("ORD-1")
+	env.createShipment("SHP-1", "ORD-1")
+	env.createShipment("SHP-10", "ORD-1")
+
+	if events := env.timeline("SHP-1"); events == nil || len(events) != 0 {
+		t.Errorf("new shipment has tracking events: %+v", events)
+	}
+
+	scans := []struct{ statusCode, location, carrierTimestamp string }{
+		{"AC", "Rotterdam", "2024-03-02T08:00:00Z"},
+		{"IT", "Antwerp", "2024-03-02T14:30:00+02:00"},
+		// Posted late, scanned before the previous one
+		{"IT", "Breda", "2024-03-02T11:00:00Z"},
+		{"DE", "Brussels", "2024-03-03T09:15:00Z"},
+	}
+	for _, scan := range scans {
+		if err := env.addTrackingEvent("SHP-1", scan.statusCode, scan.location, scan.carrierTimestamp); err != nil {
+			t.Fatalf("scan at %s rejected: %v", scan.location, err)
+		}
+	}
+	if err := env.addTrackingEvent("SHP-10", "AC", "Hamburg", "2024-03-02T08:00:00Z"); err != nil {
+		t.Fatal(err)
+	}
+	if event := env.lastEvent(); event.Name != EventTrackingEventAdded {
+		t.Errorf("event = %s, want %s", event.Name, EventTrackingEventAdded)
+	}
+
+	events := env.timeline("SHP-1")
+	want := []string{"Rotterdam", "Breda", "Antwerp", "Brussels"}
+	if len(events) != len(want) {
+		t.Fatalf("got %d tracking events, want %d", len(events), len(want))
+	}
+	for i, location := range want {
+		if events[i].Location != location {
+			t.Errorf("event %d at %s, want %s", i, events[i].Location, location)
+		}
+	}
+	antwerp := events[2]
+	if antwerp.Sequence != 2 || antwerp.CarrierTimestamp != "2024-03-02T12:30:00Z" || antwerp.ShipmentID != "SHP-1" {
+		t.Errorf("unexpected Antwerp scan: %+v", antwerp)
+	}
+	if antwerp.Actor != testIdentity(RoleCarrier).ID || antwerp.MSPID != testMSPID(RoleCarrier) || antwerp.TxID == "" || antwerp.RecordedAt == "" {
+		t.Errorf("scan does not record the carrier: %+v", antwerp)
+	}
+
+	var shipment ShipEngineData
+	if err := json.Unmarshal(env.stub.CommittedState(shipmentKey(t, env, "SHP-1")), &shipment); err != nil {
+		t.Fatal(err)
+	}
+	if shipment.Status != "DE" || shipment.TrackingEvents != 4 {
+		t.Errorf("shipment does not reflect its tracking events: %+v", shipment)
+	}
+
+	entries := env.auditTrail("shipment:SHP-1")
+	if len(entries) != 1+len(scans) || entries[len(entries)-1].Action != EventTrackingEventAdded {
+		t.Errorf("unexpected audit trail of the shipment: %+v", entries)
+	}
+	if events := env.timeline("SHP-10"); len(events) != 1 || events[0].Location != "Hamburg" {
+		t.Errorf("unexpected timeline of SHP-10: %+v", events)
+	}
+}
+
+// shipmentKey returns the world state key of a shipment
+func shipmentKey(t *testing.T, env *testEnv, id string) string {
+	t.Helper()
+	key, err := env.stub.CreateCompositeKey(shipmentObjectType, []string{id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestAddTrackingEventErrors(t *testing.T) {
+	env := newTestEnv(t)
+	env.createOrder("ORD-1")
+	env.createShipment("SHP-1", "ORD-1")
+
+	invalid := []struct{ id, statusCode, carrierTimestamp string }{
+		{"SHP-2", "IT", "2024-03-02T08:00:00Z"},
+		{"SHP-1", "XX", "2024-03-02T08:00:00Z"},
+		{"SHP-1", "it", "2024-03-02T08:00:00Z"},
+		{"SHP-1", "IT", "2024-03-02 08:00"},
+		{"SHP-1", "IT", ""},
+	}
+	for i, tt := range invalid {
+		if err := env.addTrackingEvent(tt.id, tt.statusCode, "Rotterdam", tt.carrierTimestamp); err == nil {
+			t.Errorf("case %d: invalid scan %+v accepted", i, tt)
+		}
+	}
+
+	for _, role := range []string{RoleSeller, RoleBuyer, RoleAuditor} {
+		err := env.submit(role, "AddTrackingEvent", func(ctx contractapi.TransactionContextInterface) error {
+			return env.contract.AddTrackingEvent(ctx, "SHP-1", "IT", "Rotterdam", "2024-03-02T08:00:00Z", "")
+		})
+		if err == nil {
+			t.Errorf("%s posted a tracking event", role)
+		}
+	}
+
+	err := env.submit(RoleAuditor, "GetShipmentTimeline", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.contract.GetShipmentTimeline(ctx, "SHP-2")
+		return err
+	})
+	if err == nil {
+		t.Error("timeline of a missing shipment returned")
+	}
+	if events := env.timeline("SHP-1"); len(events) != 0 {
+		t.Errorf("rejected scans were recorded: %+v", events)
+	}
+
+	// A missing shipment is reported as such, other read failures are not
+	if err := env.addTrackingEvent("SHP-2", "IT", "Rotterdam", "2024-03-02T08:00:00Z"); errorCode(err) != CodeNotFound {
+		t.Errorf("scan of a missing shipment: got %v", err)
+	}
+	key, _ := env.stub.CreateCompositeKey(shipmentObjectType, []string{"SHP-3"})
+	env.stub.PutCommittedState(key, []byte(`{"id":`))
+	if err := env.addTrackingEvent("SHP-3", "IT", "Rotterdam", "2024-03-02T08:00:00Z"); errorCode(err) != CodeInternal {
+		t.Errorf("scan of an undecodable shipment: got %v", err)
+	}
+}
@@ -0,0 +1,239 @@
+package ordermanagement
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Limits of the validated arguments
+const (
+	maxIDLength   = 64
+	maxTextLength = 1024
+)
+
+// idPattern matches the IDs of orders, payments and shipments, e.g. ORD-1 or logis_ordr_1.
+// Colons are left out since they separate the type and ID of an asset key
+var idPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// urlPattern matches an http or https URL
+var urlPattern = regexp.MustCompile(`^https?://[^\s]+$`)
+
+// FieldError reports why a single argument was rejected. Rule is the name of the failed
+// rule: required, length, pattern, enum, date, timestamp, amount, range or private
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ValidationError reports every rejected argument of a call at once. It is returned as an
+// INVALID_ARGUMENT ContractError listing the failing fields
+type ValidationError struct {
+	Function string
+	Errors   []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	return e.contractError().Error()
+}
+
+func (e *ValidationError) contractError() *ContractError {
+	err := newError(CodeInvalidArgument, map[string]string{"function": e.Function}, "invalid arguments to %s", e.Function)
+	err.Errors = e.Errors
+	return err
+}
+
+// rule checks a single argument and returns the reason it is rejected, or nil. Every rule
+// but required accepts an empty value, so optional arguments only list the other rules
+type rule func(value string) *FieldError
+
+// field is an argument together with the rules it must satisfy
+type field struct {
+	name  string
+	value string
+	rules []rule
+}
+
+// arg declares the rules of an argument
+func arg(name string, value string, rules ...rule) field {
+	return field{name: name, value: value, rules: rules}
+}
+
+// newIDArg declares the ID of an asset being created
+func newIDArg(name string, value string) field {
+	return arg(name, value, required, maxLength(maxIDLength), matches(idPattern, "letters, digits, '_', '.' and '-' starting with a letter or digit"))
+}
+
+// idArg declares the ID of an existing asset. Only new IDs must follow idPattern, so that
+// the assets created before it remain reachable
+func idArg(name string, value string) field {
+	return arg(name, value, required)
+}
+
+// intArg declares an integer argument
+func intArg(name string, value int, rules ...rule) field {
+	return arg(name, strconv.Itoa(value), rules...)
+}
+
+// textArg declares an optional free text argument
+func textArg(name string, value string) field {
+	return arg(name, value, maxLength(maxTextLength))
+}
+
+// privateArg declares an argument holding commercially sensitive details, which must be
+// passed in the transient map instead so that they never reach the blocks. present is set
+// when the caller passed a value as a parameter
+func privateArg(name string, present bool) field {
+	value := ""
+	if present {
+		value = name
+	}
+	return arg(name, value, transientOnly)
+}
+
+// validate applies the rules of every argument of the called function and reports all
+// rejected arguments together. The rules of an argument stop at its first failure
+func validate(ctx contractapi.TransactionContextInterface, fields ...field) error {
+	var errors []FieldError
+	for _, f := range fields {
+		for _, r := range f.rules {
+			if fieldError := r(f.value); fieldError != nil {
+				fieldError.Field = f.name
+				errors = append(errors, *fieldError)
+				break
+			}
+		}
+	}
+	if len(errors) == 0 {
+		return nil
+	}
+
+	function, _ := ctx.GetStub().GetFunctionAndParameters()
+	return &ValidationError{Function: functionName(function), Errors: errors}
+}
+
+// required rejects empty and blank values
+func required(value string) *FieldError {
+	if strings.TrimSpace(value) == "" {
+		return &FieldError{Rule: "required", Message: "must not be empty"}
+	}
+	return nil
+}
+
+// maxLength rejects values longer than the given number of characters
+func maxLength(max int) rule {
+	return func(value string) *FieldError {
+		if utf8.RuneCountInString(value) > max {
+			return &FieldError{Rule: "length", Message: fmt.Sprintf("must be at most %d characters long", max)}
+		}
+		return nil
+	}
+}
+
+// matches rejects values not matching the pattern, described in the message
+func matches(pattern *regexp.Regexp, description string) rule {
+	return func(value string) *FieldError {
+		if value != "" && !pattern.MatchString(value) {
+			return &FieldError{Rule: "pattern", Message: "must be " + description}
+		}
+		return nil
+	}
+}
+
+// oneOf rejects values other than the listed ones
+func oneOf(values ...string) rule {
+	return func(value string) *FieldError {
+		if value != "" && !contains(values, value) {
+			return &FieldError{Rule: "enum", Message: fmt.Sprintf("must be one of %s", strings.Join(values, ", "))}
+		}
+		return nil
+	}
+}
+
+// isoDate rejects values that are not a date of the form YYYY-MM-DD
+func isoDate(value string) *FieldError {
+	if _, err := time.Parse(dateLayout, value); value != "" && err != nil {
+		return &FieldError{Rule: "date", Message: "must be a date of the form YYYY-MM-DD"}
+	}
+	return nil
+}
+
+// rfc3339 rejects values that are not an RFC3339 timestamp
+func rfc3339(value string) *FieldError {
+	if _, err := time.Parse(time.RFC3339, value); value != "" && err != nil {
+		return &FieldError{Rule: "timestamp", Message: "must be an RFC3339 timestamp such as 2024-03-01T10:00:00Z"}
+	}
+	return nil
+}
+
+// transientOnly rejects any value, see privateArg
+func transientOnly(value string) *FieldError {
+	if value != "" {
+		return &FieldError{Rule: "private", Message: fmt.Sprintf("must be passed in the transient map under %q", privateTransientKey)}
+	}
+	return nil
+}
+
+// atLeast rejects integers below min
+func atLeast(min int) rule {
+	return func(value string) *FieldError {
+		if n, err := strconv.Atoi(value); value != "" && (err != nil || n < min) {
+			return &FieldError{Rule: "range", Message: fmt.Sprintf("must be at least %d", min)}
+		}
+		return nil
+	}
+}
+
+// atMost rejects integers above max
+func atMost(max int) rule {
+	return func(value string) *FieldError {
+		if n, err := strconv.Atoi(value); value != "" && (err != nil || n > max) {
+			return &FieldError{Rule: "range", Message: fmt.Sprintf("must be at most %d", max)}
+		}
+		return nil
+	}
+}
+
+// positiveAmount rejects values that are not a positive decimal amount of the currency
+func positiveAmount(currency string) rule {
+	return func(value string) *FieldError {
+		if value == "" {
+			return nil
+		}
+		money, err := ParseMoney(value, currency)
+		if err != nil {
+			return &FieldError{Rule: "amount", Message: err.Error()}
+		}
+		if money.Amount <= 0 {
+			return &FieldError{Rule: "amount", Message: "must be positive"}
+		}
+		return nil
+	}
+}
+
+// sortedKeys returns the keys of a set of names in order
+func sortedKeys(names map[string]string) []string {
+	keys := make([]string, 0, len(names))
+	for key := range names {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// orderStatuses returns the names of the order lifecycle statuses in order
+func orderStatuses() []string {
+	statuses := make([]string, 0, len(orderTransitions))
+	for status := range orderTransitions {
+		statuses = append(statuses, string(status))
+	}
+	sort.Strings(statuses)
+	return statuses
+}
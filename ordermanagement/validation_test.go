@@ -0,0 +1,195 @@
+package ordermanagement
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// validationErrors decodes the JSON of a validation error, failing on any other error
+func validationErrors(t *testing.T, err error) *ContractError {
+	t.Helper()
+	if err == nil {
+		t.Fatal("invalid arguments accepted")
+	}
+	var validation *ValidationError
+	if !errors.As(err, &validation) {
+		t.Fatalf("got error %v, want a validation error", err)
+	}
+
+	var decoded ContractError
+	if err := json.Unmarshal([]byte(err.Error()), &decoded); err != nil {
+		t.Fatalf("validation error is not JSON: %v", err)
+	}
+	if decoded.Code != CodeInvalidArgument {
+		t.Fatalf("code = %s, want %s", decoded.Code, CodeInvalidArgument)
+	}
+	return &decoded
+}
+
+// fieldRules returns the failed rule of every rejected field
+func fieldRules(validation *ContractError) map[string]string {
+	rules := map[string]string{}
+	for _, fieldError := range validation.Errors {
+		rules[fieldError.Field] = fieldError.Rule
+	}
+	return rules
+}
+
+func TestCreateOrderValidation(t *testing.T) {
+	env := newTestEnv(t)
+
+	err := env.submit(RoleSeller, "CreateOrder", func(ctx contractapi.TransactionContextInterface) error {
+		return env.contract.CreateOrder(ctx, " ", "01/03/2024", strings.Repeat("x", maxTextLength+1), "Paypal")
+	})
+	validation := validationErrors(t, err)
+	if function := validation.Details["function"]; function != "CreateOrder" {
+		t.Errorf("function = %s, want CreateOrder", function)
+	}
+	want := map[string]string{"orderNo": "required", "date": "date", "orderDetail": "length", "paymentMethod": "enum"}
+	if got := fieldRules(validation); !reflect.DeepEqual(got, want) {
+		t.Errorf("rejected fields = %v, want %v", got, want)
+	}
+	for _, fieldError := range validation.Errors {
+		if fieldError.Message == "" {
+			t.Errorf("field %s rejected without a message", fieldError.Field)
+		}
+	}
+
+	for _, orderNo := range []string{"ORD 1", "order:1", "-ORD-1", strings.Repeat("A", maxIDLength+1)} {
+		err := env.submit(RoleSeller, "CreateOrder", func(ctx contractapi.TransactionContextInterface) error {
+			return env.contract.CreateOrder(ctx, orderNo, "2024-03-01", "10 pallets", "ACH")
+		})
+		if got := fieldRules(validationErrors(t, err)); len(got) != 1 || got["orderNo"] == "" {
+			t.Errorf("order number %q: rejected fields = %v", orderNo, got)
+		}
+	}
+
+	if orders := env.stub.CommittedState(orderKey(t, env, " ")); orders != nil {
+		t.Errorf("an invalid order was written: %s", orders)
+	}
+}
+
+func TestCreateTransactionValidation(t *testing.T) {
+	env := newTestEnv(t)
+	env.createOrder("ORD-1")
+
+	tests := []struct {
+		amount, currency string
+		want             map[string]string
+	}{
+		{"-100.00", "USD", map[string]string{"amount": "amount"}},
+		{"0", "USD", map[string]string{"amount": "amount"}},
+		{"ten", "USD", map[string]string{"amount": "amount"}},
+		{"", "usd", map[string]string{"amount": "required", "currency": "pattern"}},
+	}
+	for i, tt := range tests {
+		err := env.submitPrivate(RoleBank, "CreateTransaction", paymentPrivate, func(ctx contractapi.TransactionContextInterface) error {
+			return env.contract.CreateTransaction(ctx, "PAY-1", "ORD-1", "ACH", tt.amount, tt.currency, "", "deposit")
+		})
+		if got := fieldRules(validationErrors(t, err)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("case %d: rejected fields = %v, want %v", i, got, tt.want)
+		}
+	}
+}
+
+func TestLookupValidation(t *testing.T) {
+	env := newTestEnv(t)
+
+	err := env.submit(RoleAuditor, "QueryOrdersByStatus", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.contract.QueryOrdersByStatus(ctx, "LOST")
+		return err
+	})
+	if got := fieldRules(validationErrors(t, err)); got["status"] != "enum" {
+		t.Errorf("rejected fields = %v, want an enum error on status", got)
+	}
+
+	err = env.submit(RoleAuditor, "QueryOrdersByDateRange", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.contract.QueryOrdersByDateRange(ctx, "2024-02-30", "")
+		return err
+	})
+	want := map[string]string{"startDate": "date", "endDate": "required"}
+	if got := fieldRules(validationErrors(t, err)); !reflect.DeepEqual(got, want) {
+		t.Errorf("rejected fields = %v, want %v", got, want)
+	}
+
+	err = env.submit(RoleAuditor, "ReadOrder", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.contract.ReadOrder(ctx, "")
+		return err
+	})
+	if got := fieldRules(validationErrors(t, err)); got["orderNo"] != "required" {
+		t.Errorf("rejected fields = %v, want a required error on orderNo", got)
+	}
+}
+
+func TestExistsValidation(t *testing.T) {
+	env := newTestEnv(t)
+
+	checks := map[string]func(ctx contractapi.TransactionContextInterface) (bool, error){
+		"OrderExists": func(ctx contractapi.TransactionContextInterface) (bool, error) {
+			return env.contract.OrderExists(ctx, " ")
+		},
+		"TransactionExists": func(ctx contractapi.TransactionContextInterface) (bool, error) {
+			return env.contract.TransactionExists(ctx, "")
+		},
+		"ShipEngineDataExists": func(ctx contractapi.TransactionContextInterface) (bool, error) {
+			return env.contract.ShipEngineDataExists(ctx, "")
+		},
+	}
+	for function, check := range checks {
+		err := env.submit(RoleAuditor, function, func(ctx contractapi.TransactionContextInterface) error {
+			_, err := check(ctx)
+			return err
+		})
+		if got := fieldRules(validationErrors(t, err)); len(got) != 1 || (got["orderNo"] != "required" && got["id"] != "required") {
+			t.Errorf("%s: rejected fields = %v, want a required error on the ID", function, got)
+		}
+	}
+}
+
+func TestRangeValidation(t *testing.T) {
+	env := newTestEnv(t)
+	env.createOrder("ORD-1")
+
+	tests := []struct {
+		role     string
+		function string
+		field    string
+		call     func(ctx contractapi.TransactionContextInterface) error
+	}{
+		{RoleAuditor, "GetOrdersWithPagination", "pageSize", func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.contract.GetOrdersWithPagination(ctx, 0, "")
+			return err
+		}},
+		{RoleAuditor, "GetOrdersWithPagination", "pageSize", func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.contract.GetOrdersWithPagination(ctx, maxPageSize+1, "")
+			return err
+		}},
+		{RoleAuditor, "QueryOrdersWithPagination", "pageSize", func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.contract.QueryOrdersWithPagination(ctx, `{}`, -1, "")
+			return err
+		}},
+		{RoleSeller, "PatchOrder", "expectedVersion", func(ctx contractapi.TransactionContextInterface) error {
+			return env.contract.PatchOrder(ctx, "ORD-1", `{"orderDetail":"5 pallets"}`, -1)
+		}},
+	}
+	for _, tt := range tests {
+		err := env.submit(tt.role, tt.function, tt.call)
+		if got := fieldRules(validationErrors(t, err)); len(got) != 1 || got[tt.field] != "range" {
+			t.Errorf("%s: rejected fields = %v, want a range error on %s", tt.function, got, tt.field)
+		}
+	}
+
+	for _, size := range []int{0, maxBatchSizeLimit + 1} {
+		err := env.submit(RoleAdmin, "SetMaxBatchSize", func(ctx contractapi.TransactionContextInterface) error {
+			return env.contract.SetMaxBatchSize(ctx, size)
+		})
+		if got := fieldRules(validationErrors(t, err)); got["maxBatchSize"] != "range" {
+			t.Errorf("maximum batch size %d: rejected fields = %v", size, got)
+		}
+	}
+}